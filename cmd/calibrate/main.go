@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"retape_ai/internal/calibrate"
+	"retape_ai/internal/config"
+	"retape_ai/internal/detector"
+)
+
+func main() {
+	cfg := config.DefaultConfig()
+
+	labelsFlag := flag.String("labels", "voicemails/labels.csv", "Labels file: where each recording's greeting ends and its beeps")
+	syntheticFlag := flag.Int("synthetic", 400, "Synthetic labeled greetings to add to the recordings (0 for none)")
+	seedFlag := flag.Int64("seed", 1, "Seed for the synthetic greetings")
+	earlyRateFlag := flag.Float64("early-rate", 0.05, "Target early-drop rate for FusionThreshold")
+	flag.Parse()
+
+	// Labels are about the audio, so calibrate on the audio evidence alone
+	cfg.EnableSTT = false
+
+	labels, err := calibrate.LoadLabels(*labelsFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *syntheticFlag > 0 {
+		dir, err := os.MkdirTemp("", "calibrate")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer os.RemoveAll(dir)
+
+		synthetic, err := calibrate.Synthetic(dir, *syntheticFlag, *seedFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		labels = append(labels, synthetic...)
+	}
+	fmt.Printf("Calibrating on %d labeled greetings\n", len(labels))
+
+	if err := calibrateBeeps(cfg, labels); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := calibrateFusion(cfg, labels, *earlyRateFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// fusionThresholds are the FusionThreshold values tried, in ascending order
+var fusionThresholds = []float64{0.5, 0.6, 0.7, 0.8, 0.85, 0.9, 0.925, 0.95, 0.97, 0.98, 0.99, 0.995, 0.999}
+
+// calibrateFusion sweeps FusionThreshold over the labeled greetings and picks
+// the lowest one that drops early no more often than earlyRate
+func calibrateFusion(cfg *config.Config, labels []calibrate.Label, earlyRate float64) error {
+	results, err := calibrate.SweepFusion(cfg, labels, fusionThresholds)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nFusion threshold: target early-drop rate %.1f%%\n", 100*earlyRate)
+	fmt.Printf("\n  %-10s %8s %8s %9s %10s\n", "threshold", "early", "no drop", "fallback", "dead air")
+	for _, r := range results {
+		fmt.Printf("  %-10.3f %7.1f%% %8d %9d %9.2fs\n",
+			r.Threshold, 100*r.EarlyRate(), r.NoDrop, r.Fallback, r.DeadAir.Seconds())
+	}
+
+	best, ok := calibrate.PickThreshold(results, earlyRate)
+	if !ok {
+		fmt.Printf("\n  No threshold up to %.3f meets the target\n", fusionThresholds[len(fusionThresholds)-1])
+		return nil
+	}
+	fmt.Printf("\n  FusionThreshold %.3f: %.1f%% early, %.2fs mean dead air (configured: %.3f)\n",
+		best.Threshold, 100*best.EarlyRate(), best.DeadAir.Seconds(), cfg.FusionThreshold)
+	return nil
+}
+
+// calibrateBeeps fits BeepEvent.Confidence to the labeled beeps and prints
+// the coefficients for detector.BeepCalibration
+func calibrateBeeps(cfg *config.Config, labels []calibrate.Label) error {
+	samples, err := calibrate.CollectBeeps(cfg, labels)
+	if err != nil {
+		return err
+	}
+
+	scores := make([]float64, len(samples))
+	positive := make([]bool, len(samples))
+	var beeps int
+	for i, s := range samples {
+		scores[i] = s.Event.Score.Weighted()
+		positive[i] = s.IsBeep
+		if s.IsBeep {
+			beeps++
+		}
+	}
+	fmt.Printf("\nBeep confidence: %d tones reported, %d beeps, %d not beeps\n", len(samples), beeps, len(samples)-beeps)
+	if beeps == 0 || beeps == len(samples) {
+		return fmt.Errorf("need both beeps and other tones to calibrate")
+	}
+
+	fit := calibrate.FitPlatt(scores, positive)
+	fmt.Printf("  fitted:    slope %.2f, bias %.2f\n", fit.Slope, fit.Bias)
+	fmt.Printf("  committed: slope %.2f, bias %.2f (detector.BeepCalibration)\n",
+		detector.BeepCalibration.Slope, detector.BeepCalibration.Bias)
+
+	probs := make([]float64, len(scores))
+	for i, score := range scores {
+		probs[i] = fit.Apply(score)
+	}
+	fmt.Printf("\n  %-12s %6s %10s %10s\n", "confidence", "tones", "predicted", "observed")
+	for _, bin := range calibrate.Reliability(probs, positive, 5) {
+		if bin.Count > 0 {
+			fmt.Printf("  %.1f - %.1f    %6d %10.2f %10.2f\n", bin.Low, bin.High, bin.Count, bin.Predicted, bin.Observed)
+		}
+	}
+	return nil
+}
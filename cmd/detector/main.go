@@ -13,10 +13,25 @@ import (
 )
 
 func main() {
+	cfg := config.DefaultConfig()
+
 	// Parse cmd line arguments
 	dirFlag := flag.String("dir", "", "Directory containing voicemail WAV files")
 	fileFlag := flag.String("file", "", "Single WAV file to analyze")
 	noSTTFlag := flag.Bool("no-stt", false, "Disable speech-to-text (faster, uses only beep/silence detection)")
+	adaptiveSilenceFlag := flag.Bool("adaptive-silence", false, "Learn the silence confirmation window from the speaker's pauses")
+	agcFlag := flag.Bool("agc", false, "Normalize input level before detection")
+	bandPassFlag := flag.Bool("bandpass", false, "Band-limit detector input to the 300-3400 Hz telephone band")
+	refFlag := flag.String("ref", "", "Outbound reference WAV to cancel from the received audio (with -file)")
+	monitorFlag := flag.Duration("monitor", cfg.PostDropMonitor, "Keep listening this long after a drop for a late beep or resumed greeting (0 disables)")
+	latencyFlag := flag.Duration("latency-budget", 0, "Dead air to accept on confident evidence; waits scale with confidence (0 keeps fixed waits)")
+	messageFlag := flag.Duration("message", 0, "Length of the prerecorded message, to check it fits the mailbox")
+	maxRecordingFlag := flag.Duration("max-recording", 0, "Mailbox maximum recording length (with -message)")
+	rulesFlag := flag.String("rules", "", "Decision rules file (selects the rules policy)")
+	policyFlag := flag.String("policy", "", "Decision policy ("+strings.Join(engine.PolicyNames(), ", ")+")")
+	carrierFlag := flag.String("carrier", "", "Expected carrier voicemail platform for this call")
+	priorFlag := flag.String("prior", "", "How the last call to this number ended ("+engine.PriorBeep+", "+engine.PriorNoBeep+")")
+	nameFlag := flag.String("name", "", "Callee's name, to recognize it in a personal greeting")
 	flag.Parse()
 
 	if *dirFlag == "" && *fileFlag == "" {
@@ -29,6 +44,19 @@ func main() {
 		fmt.Println()
 		fmt.Println("Options:")
 		fmt.Println("  -no-stt                     Disable speech-to-text")
+		fmt.Println("  -adaptive-silence           Learn silence window from the greeting's own pauses")
+		fmt.Println("  -agc                        Normalize quiet or hot recordings before detection")
+		fmt.Println("  -bandpass                   Filter detector input to the 300-3400 Hz telephone band")
+		fmt.Println("  -ref <outbound.wav>         Cancel our own outbound audio (echo/bleed), with -file")
+		fmt.Println("  -policy <name>              Decision policy (default: priority)")
+		fmt.Println("  -message <duration>         Prerecorded message length, e.g. 25s")
+		fmt.Println("  -max-recording <duration>   Mailbox recording limit; warns when the message would be cut off")
+		fmt.Println("  -monitor <duration>         Re-drop window after a drop, e.g. 5s (default off)")
+		fmt.Println("  -rules <file.rules>         Decide with rules from a file instead of the built-in priorities")
+		fmt.Println("  -latency-budget <duration>  Dead air to accept on confident evidence, e.g. 300ms (default: fixed waits)")
+		fmt.Println("  -carrier <platform>         Expected carrier voicemail platform for this call")
+		fmt.Println("  -prior <beep|no_beep>       How the last call to this number ended")
+		fmt.Println("  -name <name>                Callee's name, to recognize it in a personal greeting")
 		fmt.Println()
 		fmt.Println("Environment Variables:")
 		fmt.Println("  DEEPGRAM_API_KEY   Optional: Enable speech-to-text for better detection")
@@ -36,11 +64,48 @@ func main() {
 		os.Exit(1)
 	}
 
-	cfg := config.DefaultConfig()
-
 	if *noSTTFlag {
 		cfg.EnableSTT = false
 	}
+	if *adaptiveSilenceFlag {
+		cfg.AdaptiveSilence = true
+	}
+	if *agcFlag {
+		cfg.EnableAGC = true
+	}
+	if *bandPassFlag {
+		cfg.EnableBandPass = true
+	}
+	if *messageFlag > 0 {
+		cfg.MessageDuration = *messageFlag
+	}
+	if *maxRecordingFlag > 0 {
+		cfg.MaxRecordingLength = *maxRecordingFlag
+	}
+	if *latencyFlag > 0 {
+		cfg.LatencyBudget = *latencyFlag
+	}
+	cfg.PostDropMonitor = *monitorFlag
+	if *rulesFlag != "" {
+		cfg.DecisionPolicy = "rules"
+		cfg.RulesFile = *rulesFlag
+	}
+	if *policyFlag != "" {
+		cfg.DecisionPolicy = *policyFlag
+	}
+	if _, err := engine.NewPolicy(cfg.DecisionPolicy, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	callContext := engine.CallContext{
+		ExpectedCarrier: *carrierFlag,
+		PriorOutcome:    *priorFlag,
+		ExpectedName:    *nameFlag,
+	}
+	if err := callContext.Validate(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	fmt.Println("╔════════════════════════════════════════════════════════════╗")
 	fmt.Println("║           Voicemail Greeting End Detector                  ║")
@@ -55,6 +120,11 @@ func main() {
 	fmt.Println()
 
 
+	if *refFlag != "" && *fileFlag == "" {
+		fmt.Fprintln(os.Stderr, "Error: -ref can only be used with -file")
+		os.Exit(1)
+	}
+
 	// Collect files to process
 	var files []string
 	
@@ -92,8 +162,9 @@ func main() {
 		fmt.Printf("\n[Processing] %s\n", filename)
 
 		eng := engine.NewDecisionEngine(cfg, cfg.SampleRate)
+		eng.SetContext(callContext)
 
-		result, err := eng.Process(file)
+		result, err := eng.ProcessWithReference(file, *refFlag)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "  Error: %v\n", err)
 			continue
@@ -112,9 +183,18 @@ func main() {
 	for _, file := range files {
 		filename := filepath.Base(file)
 		if result, ok := results[filename]; ok {
+			if !result.Drops() {
+				fmt.Printf("%-20s %-15s %s\n", filename, "no drop", result.Outcome)
+				continue
+			}
+
 			method := "unknown"
 			reasonLower := strings.ToLower(result.Reason)
-			if strings.Contains(reasonLower, "beep detected") {
+			if result.Outcome == engine.OutcomeSilentGreeting {
+				method = "Silent Greeting"
+			} else if strings.HasPrefix(reasonLower, "posterior") {
+				method = "Evidence Fusion"
+			} else if strings.Contains(reasonLower, "beep detected") {
 				method = "Beep Detection"
 			} else if strings.Contains(reasonLower, "silence") {
 				method = "Silence Detection"
@@ -123,6 +203,12 @@ func main() {
 			} else {
 				method = "Fallback"
 			}
+			if result.Outcome == engine.OutcomeBeepOnly {
+				method += " (beep only)"
+			}
+			if len(result.DropTimes) > 1 {
+				method += fmt.Sprintf(" (re-drop, first at %.2fs)", result.DropTimes[0].Seconds())
+			}
 
 			fmt.Printf("%-20s %-15s %s\n", filename, fmt.Sprintf("%.2fs", result.RecommendedDropTime.Seconds()), method)
 		}
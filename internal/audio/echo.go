@@ -0,0 +1,165 @@
+package audio
+
+import (
+	"math"
+
+	"retape_ai/internal/config"
+)
+
+const (
+	geigelThreshold = 0.5  // near-end peak vs reference peak that signals double talk
+	doubleTalkHold  = 30   // ms adaptation stays frozen after double talk
+	echoMinShare    = 0.1  // echo estimate vs received power to count as bleed
+	referenceFloor  = 1e-4 // reference power per sample below which it's silent
+	nlmsRegularizer = 1e-6
+)
+
+// EchoStats describes the echo canceller's view of one chunk
+type EchoStats struct {
+	ReferenceActive bool    // our outbound audio is playing
+	DoubleTalk      bool    // the far end is talking over it, adaptation frozen
+	EchoPresent     bool    // the outbound audio is audible in the received channel
+	ERLE            float64 // echo return loss enhancement for the chunk, dB
+}
+
+// EchoCanceller removes our own outbound audio (ringback, early media, the
+// start of our message) from the received channel. An NLMS adaptive filter
+// models the echo path from the reference signal; a Geigel detector freezes
+// adaptation while the far end talks, and residual echo is attenuated when
+// the reference plays alone.
+type EchoCanceller struct {
+	config     *config.Config
+	sampleRate int
+
+	weights  []float64
+	history  []float64 // reference samples, circular, newest at pos
+	pos      int
+	refPower float64 // sum of squares over history
+
+	peaks      []float64 // per-chunk reference peaks covering the tail
+	holdLeft   int
+	holdLength int
+}
+
+func NewEchoCanceller(cfg *config.Config, sampleRate int) *EchoCanceller {
+	taps := int(cfg.EchoTailLength.Seconds() * float64(sampleRate))
+	if taps < 1 {
+		taps = 1
+	}
+
+	return &EchoCanceller{
+		config:     cfg,
+		sampleRate: sampleRate,
+		weights:    make([]float64, taps),
+		history:    make([]float64, taps),
+		holdLength: doubleTalkHold * sampleRate / 1000,
+	}
+}
+
+// Process cancels the reference out of the chunk. reference holds the
+// outbound samples time-aligned with the chunk; missing samples count as silence.
+func (c *EchoCanceller) Process(chunk AudioChunk, reference []float64) (AudioChunk, EchoStats) {
+	var stats EchoStats
+	if len(chunk.Samples) == 0 {
+		return chunk, stats
+	}
+
+	refPeak := 0.0
+	for i := range chunk.Samples {
+		if i < len(reference) {
+			refPeak = math.Max(refPeak, math.Abs(reference[i]))
+		}
+	}
+	tailPeak := c.tailPeak(refPeak)
+
+	taps := len(c.weights)
+	out := make([]float64, len(chunk.Samples))
+	var nearPower, errPower, echoPower float64
+	doubleTalkSamples := 0
+
+	for i, d := range chunk.Samples {
+		x := 0.0
+		if i < len(reference) {
+			x = reference[i]
+		}
+
+		// Slide the reference history
+		c.pos = (c.pos + 1) % taps
+		old := c.history[c.pos]
+		c.history[c.pos] = x
+		c.refPower += x*x - old*old
+		if c.refPower < 0 {
+			c.refPower = 0
+		}
+
+		// Echo estimate: weights[k] applies to the sample k steps back
+		var estimate float64
+		idx := c.pos
+		for k := 0; k < taps; k++ {
+			estimate += c.weights[k] * c.history[idx]
+			idx--
+			if idx < 0 {
+				idx = taps - 1
+			}
+		}
+		e := d - estimate
+
+		if math.Abs(d) > geigelThreshold*tailPeak && tailPeak > 0 {
+			c.holdLeft = c.holdLength
+		}
+		doubleTalk := c.holdLeft > 0
+		if doubleTalk {
+			c.holdLeft--
+			doubleTalkSamples++
+		} else if c.refPower > referenceFloor*float64(taps) {
+			step := c.config.EchoStepSize * e / (c.refPower + nlmsRegularizer)
+			idx = c.pos
+			for k := 0; k < taps; k++ {
+				c.weights[k] += step * c.history[idx]
+				idx--
+				if idx < 0 {
+					idx = taps - 1
+				}
+			}
+		}
+
+		nearPower += d * d
+		errPower += e * e
+		echoPower += estimate * estimate
+		out[i] = e
+	}
+
+	n := float64(len(chunk.Samples))
+	stats.ReferenceActive = c.refPower/float64(taps) > referenceFloor
+	stats.DoubleTalk = doubleTalkSamples > len(chunk.Samples)/2
+	stats.EchoPresent = stats.ReferenceActive && echoPower >= echoMinShare*nearPower && nearPower > 0
+	if errPower > 0 && nearPower > 0 {
+		stats.ERLE = 10 * math.Log10(nearPower/n/(errPower/n))
+	}
+
+	// Residual echo suppression: with only our audio playing, whatever the
+	// filter left behind is still echo
+	if stats.EchoPresent && !stats.DoubleTalk {
+		for i := range out {
+			out[i] *= c.config.EchoSuppressionGain
+		}
+	}
+
+	chunk.Samples = out
+	return chunk, stats
+}
+
+// tailPeak keeps the reference peak over the echo tail, chunk by chunk
+func (c *EchoCanceller) tailPeak(chunkPeak float64) float64 {
+	c.peaks = append(c.peaks, chunkPeak)
+	chunks := int(c.config.EchoTailLength/c.config.ChunkDuration) + 1
+	if len(c.peaks) > chunks {
+		c.peaks = c.peaks[1:]
+	}
+
+	peak := 0.0
+	for _, p := range c.peaks {
+		peak = math.Max(peak, p)
+	}
+	return peak
+}
@@ -0,0 +1,201 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"retape_ai/internal/config"
+)
+
+const (
+	telephonyLowHz  = 300.0
+	telephonyHighHz = 3400.0
+	humNotchQ       = 30.0
+	humPeakRatio    = 4.0   // harmonic vs the spectrum halfway to its neighbours
+	humMaxFreq      = 400.0 // Q=30 notches higher up would cut into beep tones at those harmonics
+)
+
+// biquad is a direct form I second-order IIR section (RBJ cookbook coefficients)
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func newBiquad(b0, b1, b2, a0, a1, a2 float64) *biquad {
+	return &biquad{b0: b0 / a0, b1: b1 / a0, b2: b2 / a0, a1: a1 / a0, a2: a2 / a0}
+}
+
+func newHighPass(freq, q float64, sampleRate int) *biquad {
+	w, alpha := biquadParams(freq, q, sampleRate)
+	cos := math.Cos(w)
+	return newBiquad((1+cos)/2, -(1 + cos), (1+cos)/2, 1+alpha, -2*cos, 1-alpha)
+}
+
+func newLowPass(freq, q float64, sampleRate int) *biquad {
+	w, alpha := biquadParams(freq, q, sampleRate)
+	cos := math.Cos(w)
+	return newBiquad((1-cos)/2, 1-cos, (1-cos)/2, 1+alpha, -2*cos, 1-alpha)
+}
+
+func newNotch(freq, q float64, sampleRate int) *biquad {
+	w, alpha := biquadParams(freq, q, sampleRate)
+	cos := math.Cos(w)
+	return newBiquad(1, -2*cos, 1, 1+alpha, -2*cos, 1-alpha)
+}
+
+func biquadParams(freq, q float64, sampleRate int) (float64, float64) {
+	w := 2 * math.Pi * freq / float64(sampleRate)
+	return w, math.Sin(w) / (2 * q)
+}
+
+func (b *biquad) process(x float64) float64 {
+	y := b.b0*x + b.b1*b.x1 + b.b2*b.x2 - b.a1*b.y1 - b.a2*b.y2
+	b.x2, b.x1 = b.x1, x
+	b.y2, b.y1 = b.y1, y
+	return y
+}
+
+// HumEvent reports mains hum found on the line and the notches installed for it
+type HumEvent struct {
+	Frequency float64 // 50 or 60 Hz
+	Notches   []float64
+}
+
+// TelephonyFilter limits detector input to the 300-3400 Hz telephone band and
+// notches out mains hum. Hum is detected from the first seconds of audio: the
+// 50 or 60 Hz series with clear spectral peaks at its harmonics gets a notch
+// on every harmonic below 400 Hz that stands out. Higher harmonics are left
+// alone: they are weak on real lines and share frequencies with beeps.
+type TelephonyFilter struct {
+	config     *config.Config
+	sampleRate int
+
+	bandPass []*biquad
+	notches  []*biquad
+	hum      *HumEvent
+
+	analysis   []float64
+	blockSize  int
+	blocksLeft int
+}
+
+func NewTelephonyFilter(cfg *config.Config, sampleRate int) *TelephonyFilter {
+	f := &TelephonyFilter{
+		config:     cfg,
+		sampleRate: sampleRate,
+		blockSize:  sampleRate / 2, // 500ms gives ~2 Hz resolution to tell 50 from 60 Hz series
+		blocksLeft: int(cfg.HumDetectWindow / (500 * time.Millisecond)),
+	}
+
+	if cfg.EnableBandPass {
+		// One Butterworth section per edge: steeper cascades ring long enough
+		// to smear the onset of short beeps
+		f.bandPass = append(f.bandPass, newHighPass(telephonyLowHz, math.Sqrt2/2, sampleRate))
+		if telephonyHighHz < float64(sampleRate)/2*0.9 {
+			f.bandPass = append(f.bandPass, newLowPass(telephonyHighHz, math.Sqrt2/2, sampleRate))
+		}
+	}
+
+	return f
+}
+
+// Process filters the chunk and returns a HumEvent the first time hum is found
+func (f *TelephonyFilter) Process(chunk AudioChunk) (AudioChunk, *HumEvent) {
+	var event *HumEvent
+	if f.config.EnableHumNotch && f.hum == nil && f.blocksLeft > 0 {
+		f.analysis = append(f.analysis, chunk.Samples...)
+		if len(f.analysis) >= f.blockSize {
+			f.hum = f.detectHum(f.analysis[:f.blockSize])
+			f.analysis = f.analysis[:0]
+			f.blocksLeft--
+			event = f.hum
+		}
+	}
+
+	if len(f.bandPass) == 0 && len(f.notches) == 0 {
+		return chunk, event
+	}
+
+	out := make([]float64, len(chunk.Samples))
+	for i, x := range chunk.Samples {
+		for _, b := range f.notches {
+			x = b.process(x)
+		}
+		for _, b := range f.bandPass {
+			x = b.process(x)
+		}
+		out[i] = x
+	}
+	chunk.Samples = out
+	return chunk, event
+}
+
+func (f *TelephonyFilter) Hum() *HumEvent {
+	return f.hum
+}
+
+func (f *TelephonyFilter) detectHum(block []float64) *HumEvent {
+	var best *HumEvent
+	var bestScore float64
+
+	for _, mains := range []float64{50, 60} {
+		var notches []float64
+		var score float64
+		lowPeaks := 0
+		for k := 1; float64(k)*mains < humMaxFreq; k++ {
+			freq := float64(k) * mains
+			peak := blockAmplitude(block, freq, f.sampleRate)
+			if peak < f.config.HumMinAmplitude {
+				continue
+			}
+			around := math.Max(
+				blockAmplitude(block, freq-mains/2, f.sampleRate),
+				blockAmplitude(block, freq+mains/2, f.sampleRate),
+			)
+			if peak < around*humPeakRatio {
+				continue
+			}
+			notches = append(notches, freq)
+			score += peak
+			if k <= 3 {
+				lowPeaks++
+			}
+		}
+
+		// Hum always shows in the lowest harmonics; one low peak alone may be a
+		// steady voice pitch and a lone peak higher up is a tone
+		if lowPeaks >= 2 && score > bestScore {
+			bestScore = score
+			best = &HumEvent{Frequency: mains, Notches: notches}
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+	for _, freq := range best.Notches {
+		f.notches = append(f.notches, newNotch(freq, humNotchQ, f.sampleRate))
+	}
+	return best
+}
+
+func (e *HumEvent) String() string {
+	return fmt.Sprintf("%.0f Hz hum, %d harmonics notched", e.Frequency, len(e.Notches))
+}
+
+// blockAmplitude is the Goertzel amplitude of one frequency over the block
+func blockAmplitude(samples []float64, freq float64, sampleRate int) float64 {
+	w := 2 * math.Pi * freq / float64(sampleRate)
+	coeff := 2 * math.Cos(w)
+	var s1, s2 float64
+	for _, x := range samples {
+		s0 := x + coeff*s1 - s2
+		s2, s1 = s1, s0
+	}
+	power := s1*s1 + s2*s2 - coeff*s1*s2
+	if power < 0 {
+		power = 0
+	}
+	return 2 * math.Sqrt(power) / float64(len(samples))
+}
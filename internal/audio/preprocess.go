@@ -0,0 +1,142 @@
+package audio
+
+import (
+	"math"
+
+	"retape_ai/internal/config"
+)
+
+// Samples within this many consecutive samples at the rail count as clipping;
+// a single full-scale sample is just a loud peak
+const clipMinRun = 3
+
+// AGC output passes through linearly up to this level, then soft limits
+const agcKnee = 0.8
+
+// PreprocessStats describes what the preprocessing chain saw and did to one chunk
+type PreprocessStats struct {
+	ClippedSamples int     // samples in runs at or beyond ClipLevel
+	Clipped        bool    // chunk contains at least one clipped run
+	DCOffset       float64 // mean of the raw chunk
+	Gain           float64 // AGC gain applied (1 when AGC is off)
+}
+
+// Preprocessor conditions raw samples before they reach the detectors: it
+// flags clipping on the raw signal, removes DC offset with a one-pole
+// high-pass filter and optionally normalizes the level with a slow AGC.
+type Preprocessor struct {
+	config     *config.Config
+	sampleRate int
+
+	// DC blocker state: y[n] = x[n] - x[n-1] + r*y[n-1]
+	dcR      float64
+	prevIn   float64
+	prevOut  float64
+	dcPrimed bool
+	agcLevel float64 // peak-hold RMS estimate
+}
+
+func NewPreprocessor(cfg *config.Config, sampleRate int) *Preprocessor {
+	r := 0.0
+	if sampleRate > 0 {
+		r = math.Exp(-2 * math.Pi * cfg.DCCutoffHz / float64(sampleRate))
+	}
+
+	return &Preprocessor{
+		config:     cfg,
+		sampleRate: sampleRate,
+		dcR:        r,
+	}
+}
+
+// Process returns a conditioned copy of the chunk; the input samples are not modified
+func (p *Preprocessor) Process(chunk AudioChunk) (AudioChunk, PreprocessStats) {
+	stats := PreprocessStats{Gain: 1}
+	if !p.config.EnablePreprocessing || len(chunk.Samples) == 0 {
+		return chunk, stats
+	}
+
+	stats.ClippedSamples = countClipped(chunk.Samples, p.config.ClipLevel)
+	stats.Clipped = stats.ClippedSamples > 0
+
+	out := make([]float64, len(chunk.Samples))
+	var sum float64
+	for i, x := range chunk.Samples {
+		sum += x
+		if !p.dcPrimed {
+			// Start the filter at the first sample's level to avoid a step transient
+			p.prevIn = x
+			p.dcPrimed = true
+		}
+		y := x - p.prevIn + p.dcR*p.prevOut
+		p.prevIn = x
+		p.prevOut = y
+		out[i] = y
+	}
+	stats.DCOffset = sum / float64(len(chunk.Samples))
+
+	if p.config.EnableAGC {
+		stats.Gain = p.applyAGC(out)
+	}
+
+	chunk.Samples = out
+	return chunk, stats
+}
+
+// applyAGC raises quiet recordings toward AGCTargetRMS. The gain follows a peak-hold
+// level estimate that decays slowly (half every 3s), so pauses and line noise
+// don't pump the gain up and beeps keep their level relative to the speech.
+func (p *Preprocessor) applyAGC(samples []float64) float64 {
+	var sumSq float64
+	for _, s := range samples {
+		sumSq += s * s
+	}
+	rms := math.Sqrt(sumSq / float64(len(samples)))
+
+	chunkSeconds := float64(len(samples)) / float64(p.sampleRate)
+	p.agcLevel *= math.Pow(0.5, chunkSeconds/3)
+	if rms >= p.config.AGCGateRMS && rms > p.agcLevel {
+		p.agcLevel = rms
+	}
+
+	// Only boost: attenuating a hot recording would push beeps under
+	// BeepMinAmplitude and can't undo clipping anyway
+	gain := 1.0
+	if p.agcLevel > 0 {
+		gain = math.Max(1, math.Min(p.config.AGCTargetRMS/p.agcLevel, p.config.AGCMaxGain))
+	}
+
+	for i, s := range samples {
+		samples[i] = softLimit(s * gain)
+	}
+	return gain
+}
+
+// softLimit keeps boosted peaks from turning into new clipping. Below the
+// knee the signal passes unchanged, so beeps and speech keep their shape;
+// above it the excess is squashed smoothly towards full scale.
+func softLimit(s float64) float64 {
+	if math.Abs(s) <= agcKnee {
+		return s
+	}
+	excess := (math.Abs(s) - agcKnee) / (1 - agcKnee)
+	return math.Copysign(agcKnee+(1-agcKnee)*math.Tanh(excess), s)
+}
+
+func countClipped(samples []float64, level float64) int {
+	clipped, run := 0, 0
+	for _, s := range samples {
+		if math.Abs(s) >= level {
+			run++
+			continue
+		}
+		if run >= clipMinRun {
+			clipped += run
+		}
+		run = 0
+	}
+	if run >= clipMinRun {
+		clipped += run
+	}
+	return clipped
+}
@@ -168,3 +168,38 @@ func (w *WAVFile) Reset() error {
 	_, err := w.file.Seek(w.DataOffset, io.SeekStart)
 	return err
 }
+
+// WriteWAV saves mono samples in -1..1 as 16-bit PCM
+func WriteWAV(path string, samples []float64, sampleRate int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer f.Close()
+
+	dataSize := uint32(2 * len(samples))
+	header := WAVHeader{
+		ChunkSize:     36 + dataSize,
+		Subchunk1Size: 16,
+		AudioFormat:   1,
+		NumChannels:   1,
+		SampleRate:    uint32(sampleRate),
+		ByteRate:      uint32(2 * sampleRate),
+		BlockAlign:    2,
+		BitsPerSample: 16,
+	}
+	copy(header.ChunkID[:], "RIFF")
+	copy(header.Format[:], "WAVE")
+	copy(header.Subchunk1ID[:], "fmt ")
+
+	pcm := make([]int16, len(samples))
+	for i, v := range samples {
+		pcm[i] = int16(max(-1, min(1, v)) * 32767)
+	}
+	for _, v := range []any{header, [4]byte{'d', 'a', 't', 'a'}, dataSize, pcm} {
+		if err := binary.Write(f, binary.LittleEndian, v); err != nil {
+			return fmt.Errorf("failed to write WAV: %w", err)
+		}
+	}
+	return f.Close()
+}
@@ -0,0 +1,48 @@
+package calibrate
+
+import (
+	"retape_ai/internal/audio"
+	"retape_ai/internal/config"
+	"retape_ai/internal/detector"
+)
+
+// BeepSample is a tone the beep detector reported in a labeled recording
+type BeepSample struct {
+	File   string
+	Event  *detector.BeepEvent
+	IsBeep bool // the tone covers a labeled beep
+}
+
+// CollectBeeps runs the beep detector, behind the engine's preprocessing,
+// over each recording and labels every tone it reports. Near misses are left
+// out: only reported beeps are ever confirmed or rejected on their
+// confidence.
+func CollectBeeps(cfg *config.Config, labels []Label) ([]BeepSample, error) {
+	var samples []BeepSample
+	for _, label := range labels {
+		streamer, err := audio.NewStreamer(label.File, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		sampleRate := streamer.SampleRate()
+		preprocessor := audio.NewPreprocessor(cfg, sampleRate)
+		filter := audio.NewTelephonyFilter(cfg, sampleRate)
+		beeps := detector.NewBeepDetector(cfg, sampleRate)
+		for chunk := range streamer.StreamWithPacing(false) {
+			chunk, _ = preprocessor.Process(chunk)
+			chunk, _ = filter.Process(chunk)
+			beeps.Process(chunk)
+		}
+
+		// Scores are final once the stream has run past each tone
+		for _, event := range beeps.Beeps() {
+			samples = append(samples, BeepSample{
+				File:   label.File,
+				Event:  event,
+				IsBeep: label.overlapsBeep(event.StartTime, event.EndTime),
+			})
+		}
+	}
+	return samples, nil
+}
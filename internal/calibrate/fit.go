@@ -0,0 +1,109 @@
+package calibrate
+
+import (
+	"math"
+
+	"retape_ai/internal/detector"
+)
+
+// FitPlatt fits a logistic curve from scores to labels by maximum likelihood with Newton's method. The
+// targets are Platt's smoothed ones, (positives+1)/(positives+2) and
+// 1/(negatives+2), so a score that separates a small set perfectly still
+// gets a finite slope.
+func FitPlatt(scores []float64, positive []bool) detector.Logistic {
+	var pos, neg float64
+	for _, isPos := range positive {
+		if isPos {
+			pos++
+		} else {
+			neg++
+		}
+	}
+	targets := make([]float64, len(scores))
+	for i, isPos := range positive {
+		if isPos {
+			targets[i] = (pos + 1) / (pos + 2)
+		} else {
+			targets[i] = 1 / (neg + 2)
+		}
+	}
+
+	fit := detector.Logistic{Bias: math.Log((pos + 1) / (neg + 1))}
+	loss := logLoss(fit, scores, targets)
+	for iter := 0; iter < 100; iter++ {
+		var gradA, gradB, hessAA, hessAB, hessBB float64
+		for i, x := range scores {
+			p := fit.Apply(x)
+			d := p - targets[i]
+			w := math.Max(p*(1-p), 1e-12)
+			gradA += d * x
+			gradB += d
+			hessAA += w * x * x
+			hessAB += w * x
+			hessBB += w
+		}
+		det := hessAA*hessBB - hessAB*hessAB
+		if det <= 0 {
+			break
+		}
+		stepA := (hessBB*gradA - hessAB*gradB) / det
+		stepB := (hessAA*gradB - hessAB*gradA) / det
+
+		// Halve the step until it improves the fit
+		for scale := 1.0; scale > 1e-6; scale /= 2 {
+			next := detector.Logistic{Slope: fit.Slope - scale*stepA, Bias: fit.Bias - scale*stepB}
+			if nextLoss := logLoss(next, scores, targets); nextLoss <= loss {
+				fit, loss = next, nextLoss
+				break
+			}
+		}
+		if math.Abs(stepA)+math.Abs(stepB) < 1e-9 {
+			break
+		}
+	}
+	return fit
+}
+
+func logLoss(fit detector.Logistic, scores, targets []float64) float64 {
+	var loss float64
+	for i, x := range scores {
+		p := math.Min(math.Max(fit.Apply(x), 1e-12), 1-1e-12)
+		loss -= targets[i]*math.Log(p) + (1-targets[i])*math.Log(1-p)
+	}
+	return loss
+}
+
+// Bin is one row of a reliability table: of the tones predicted in
+// [Low, High), how many were beeps
+type Bin struct {
+	Low       float64
+	High      float64
+	Count     int
+	Predicted float64 // mean predicted probability
+	Observed  float64 // share that were beeps
+}
+
+// Reliability bins predicted probabilities into n equal-width bins; a
+// calibrated score has Observed close to Predicted in every bin
+func Reliability(probs []float64, positive []bool, n int) []Bin {
+	bins := make([]Bin, n)
+	for i := range bins {
+		bins[i].Low = float64(i) / float64(n)
+		bins[i].High = float64(i+1) / float64(n)
+	}
+	for i, p := range probs {
+		b := &bins[min(int(p*float64(n)), n-1)]
+		b.Count++
+		b.Predicted += p
+		if positive[i] {
+			b.Observed++
+		}
+	}
+	for i := range bins {
+		if bins[i].Count > 0 {
+			bins[i].Predicted /= float64(bins[i].Count)
+			bins[i].Observed /= float64(bins[i].Count)
+		}
+	}
+	return bins
+}
@@ -0,0 +1,80 @@
+package calibrate
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"retape_ai/internal/detector"
+)
+
+func TestFitPlattRecoversCurve(t *testing.T) {
+	truth := detector.Logistic{Slope: 10, Bias: -6}
+	rng := rand.New(rand.NewSource(1))
+
+	var scores []float64
+	var positive []bool
+	for i := 0; i < 5000; i++ {
+		x := rng.Float64()
+		scores = append(scores, x)
+		positive = append(positive, rng.Float64() < truth.Apply(x))
+	}
+
+	fit := FitPlatt(scores, positive)
+	if math.Abs(fit.Slope-truth.Slope) > 1 || math.Abs(fit.Bias-truth.Bias) > 0.6 {
+		t.Fatalf("expected about %+v, got %+v", truth, fit)
+	}
+}
+
+func TestFitPlattSeparable(t *testing.T) {
+	fit := FitPlatt([]float64{0.1, 0.2, 0.3, 0.7, 0.8, 0.9}, []bool{false, false, false, true, true, true})
+	if math.IsInf(fit.Slope, 0) || math.IsNaN(fit.Slope) || fit.Slope <= 0 {
+		t.Fatalf("expected a finite positive slope, got %+v", fit)
+	}
+	if p := fit.Apply(0.9); p > 0.9 {
+		t.Fatalf("expected the smoothed targets to keep p(0.9) below 0.9 on six tones, got %.3f", p)
+	}
+}
+
+func TestParseLabel(t *testing.T) {
+	tests := []struct {
+		line  string
+		end   time.Duration
+		beeps int
+		err   bool
+	}{
+		{"vm1.wav,10.74,10.42-10.74", 10740 * time.Millisecond, 1, false},
+		{"vm4.wav,4.96,", 4960 * time.Millisecond, 0, false},
+		{"vm8.wav,6.2,3.1-3.4;5.9-6.2", 6200 * time.Millisecond, 2, false},
+		{"vm1.wav,10.74", 0, 0, true},
+		{"vm1.wav,soon,", 0, 0, true},
+		{"vm1.wav,10.74,10.74-10.42", 0, 0, true},
+		{"vm1.wav,10.74,10.42", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			label, err := parseLabel(tt.line)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", label)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if absDuration(label.GreetingEnd-tt.end) > time.Millisecond || len(label.Beeps) != tt.beeps {
+				t.Fatalf("expected end %v with %d beeps, got %+v", tt.end, tt.beeps, label)
+			}
+		})
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,134 @@
+package calibrate
+
+import (
+	"time"
+
+	"retape_ai/internal/config"
+	"retape_ai/internal/engine"
+)
+
+// earlyTolerance is how far before the labeled end a drop may land and still
+// count as on time - about the precision of the labels
+const earlyTolerance = 100 * time.Millisecond
+
+// ThresholdResult is how the fusion policy does on the labeled set at one
+// FusionThreshold
+type ThresholdResult struct {
+	Threshold float64
+	Calls     int
+	Early     int           // dropped before the greeting ended
+	NoDrop    int           // ended without playing the message
+	Fallback  int           // never reached the threshold, so the engine's ending decided
+	DeadAir   time.Duration // mean time from the end of the greeting to the decision, over on-time drops
+}
+
+func (r ThresholdResult) EarlyRate() float64 {
+	if r.Calls == 0 {
+		return 0
+	}
+	return float64(r.Early) / float64(r.Calls)
+}
+
+// SweepFusion runs the fusion policy over each recording once and reports,
+// for every threshold, where it would have dropped: the first posterior at or
+// above the threshold decides, and a call that never reaches it ends the way
+// the engine ends it without the policy.
+func SweepFusion(cfg *config.Config, labels []Label, thresholds []float64) ([]ThresholdResult, error) {
+	c := *cfg
+	c.DecisionPolicy = "fusion"
+	c.PostDropMonitor = 0
+
+	results := make([]ThresholdResult, len(thresholds))
+	deadAir := make([]time.Duration, len(thresholds))
+	for i, threshold := range thresholds {
+		results[i].Threshold = threshold
+	}
+
+	for _, label := range labels {
+		policy := newSweepPolicy(&c, thresholds)
+		eng := engine.NewDecisionEngine(&c, c.SampleRate)
+		eng.SetPolicy(policy)
+		result, err := eng.Process(label.File)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range thresholds {
+			results[i].Calls++
+			dropTime, decidedAt, drops := result.RecommendedDropTime, result.DecisionMadeAt, result.Drops()
+			if decision := policy.decisions[i]; decision != nil {
+				dropTime, decidedAt, drops = decision.DropTime, decision.DecisionTime, true
+			} else {
+				results[i].Fallback++
+			}
+
+			switch {
+			case !drops:
+				results[i].NoDrop++
+			case dropTime < label.GreetingEnd-earlyTolerance:
+				results[i].Early++
+			default:
+				deadAir[i] += max(decidedAt-label.GreetingEnd, 0)
+			}
+		}
+	}
+
+	for i := range results {
+		if onTime := results[i].Calls - results[i].Early - results[i].NoDrop; onTime > 0 {
+			results[i].DeadAir = deadAir[i] / time.Duration(onTime)
+		}
+	}
+	return results, nil
+}
+
+// PickThreshold returns the lowest threshold, so the least dead air, whose
+// early-drop rate is at most maxEarlyRate. Results must be in ascending
+// threshold order.
+func PickThreshold(results []ThresholdResult, maxEarlyRate float64) (ThresholdResult, bool) {
+	for _, r := range results {
+		if r.EarlyRate() <= maxEarlyRate {
+			return r, true
+		}
+	}
+	return ThresholdResult{}, false
+}
+
+// sweepPolicy evaluates the fusion policy at every threshold in one pass. It
+// never decides itself, so the call runs on and the posterior keeps rising;
+// each threshold keeps the first decision the fusion policy would have made
+// there.
+type sweepPolicy struct {
+	fusion     engine.FusionPolicy
+	config     config.Config // FusionThreshold 0, so every complete Decide returns its drop
+	thresholds []float64
+	decisions  []*engine.Decision
+}
+
+func newSweepPolicy(cfg *config.Config, thresholds []float64) *sweepPolicy {
+	p := &sweepPolicy{config: *cfg, thresholds: thresholds, decisions: make([]*engine.Decision, len(thresholds))}
+	p.config.FusionThreshold = 0
+	return p
+}
+
+func (p *sweepPolicy) Name() string {
+	return "fusion"
+}
+
+func (p *sweepPolicy) Decide(s *engine.State, now time.Duration) *engine.Decision {
+	state := *s
+	state.Config = &p.config
+	decision := p.fusion.Decide(&state, now)
+	if decision == nil {
+		return nil
+	}
+	for i, threshold := range p.thresholds {
+		if p.decisions[i] == nil && p.fusion.Posterior() >= threshold {
+			p.decisions[i] = decision
+		}
+	}
+	return nil
+}
+
+func (p *sweepPolicy) Final(s *engine.State, total time.Duration) engine.Decision {
+	return p.fusion.Final(s, total)
+}
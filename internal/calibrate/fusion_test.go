@@ -0,0 +1,54 @@
+package calibrate
+
+import (
+	"testing"
+
+	"retape_ai/internal/config"
+)
+
+func TestPickThreshold(t *testing.T) {
+	results := []ThresholdResult{
+		{Threshold: 0.5, Calls: 20, Early: 6},
+		{Threshold: 0.9, Calls: 20, Early: 2},
+		{Threshold: 0.99, Calls: 20, Early: 0},
+	}
+	tests := []struct {
+		rate      float64
+		threshold float64
+		ok        bool
+	}{
+		{0.5, 0.5, true},
+		{0.1, 0.9, true},
+		{0.05, 0.99, true},
+		{0, 0.99, true},
+		{-1, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := PickThreshold(results, tt.rate)
+		if ok != tt.ok || got.Threshold != tt.threshold {
+			t.Fatalf("rate %.2f: expected %.2f (%v), got %.2f (%v)", tt.rate, tt.threshold, tt.ok, got.Threshold, ok)
+		}
+	}
+}
+
+func TestSweepFusionStricterIsLater(t *testing.T) {
+	labels, err := Synthetic(t.TempDir(), 20, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.EnableSTT = false
+
+	results, err := SweepFusion(cfg, labels, []float64{0.5, 0.95})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loose, strict := results[0], results[1]
+	if loose.Calls != len(labels) || strict.Calls != len(labels) {
+		t.Fatalf("expected %d calls per threshold, got %d and %d", len(labels), loose.Calls, strict.Calls)
+	}
+	if strict.Early > loose.Early || strict.DeadAir < loose.DeadAir {
+		t.Fatalf("expected a stricter threshold to drop early no more often and decide later, got %+v and %+v", loose, strict)
+	}
+}
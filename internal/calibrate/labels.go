@@ -0,0 +1,117 @@
+// Package calibrate fits the detector's hand-set scores to labeled
+// recordings: the beep confidence to a probability, and the fusion
+// threshold to a target early-drop rate.
+package calibrate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Span is a stretch of a recording, e.g. one beep
+type Span struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Label is the ground truth for one recording: where the greeting ends
+// (the end of the record beep, or the start of the silence after the last
+// word) and every beep in it
+type Label struct {
+	File        string
+	GreetingEnd time.Duration
+	Beeps       []Span
+}
+
+// overlapsBeep reports whether a detected tone covers a labeled beep
+func (l Label) overlapsBeep(start, end time.Duration) bool {
+	for _, beep := range l.Beeps {
+		if start < beep.End && end > beep.Start {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadLabels reads a labels file: one "file,greeting_end,beeps" line per
+// recording, times in seconds, beeps as start-end pairs separated by ';'.
+// Blank lines, '#' comments and the header line are skipped. Files are
+// relative to the labels file.
+func LoadLabels(path string) ([]Label, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var labels []Label
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "file,") {
+			continue
+		}
+
+		label, err := parseLabel(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		label.File = filepath.Join(filepath.Dir(path), label.File)
+		labels = append(labels, label)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func parseLabel(line string) (Label, error) {
+	fields := strings.Split(line, ",")
+	if len(fields) != 3 {
+		return Label{}, fmt.Errorf("expected file,greeting_end,beeps, got %d fields", len(fields))
+	}
+
+	end, err := parseSeconds(fields[1])
+	if err != nil {
+		return Label{}, err
+	}
+	label := Label{File: strings.TrimSpace(fields[0]), GreetingEnd: end}
+
+	for _, pair := range strings.Split(fields[2], ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		bounds := strings.Split(pair, "-")
+		if len(bounds) != 2 {
+			return Label{}, fmt.Errorf("beep %q: expected start-end", pair)
+		}
+		start, err := parseSeconds(bounds[0])
+		if err != nil {
+			return Label{}, err
+		}
+		end, err := parseSeconds(bounds[1])
+		if err != nil {
+			return Label{}, err
+		}
+		if end <= start {
+			return Label{}, fmt.Errorf("beep %q ends before it starts", pair)
+		}
+		label.Beeps = append(label.Beeps, Span{Start: start, End: end})
+	}
+	return label, nil
+}
+
+func parseSeconds(s string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("bad time %q", s)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,162 @@
+package calibrate
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"time"
+
+	"retape_ai/internal/audio"
+)
+
+// SyntheticSampleRate matches the sample voicemails
+const SyntheticSampleRate = 8000
+
+// Synthetic writes n labeled greetings to dir and returns their labels. Each
+// is speech with pauses of varying length, a few of them long, sometimes with a tone in a pause
+// that is not a beep (notes of music, a DTMF digit, a chirp, a whistle). Most
+// end in a beep, some in silence, and some beeps are intermediate - the
+// greeting carries on after them. The same seed gives the same set.
+func Synthetic(dir string, n int, seed int64) ([]Label, error) {
+	rng := rand.New(rand.NewSource(seed))
+	labels := make([]Label, 0, n)
+
+	for i := 0; i < n; i++ {
+		g := &greeting{rng: rng, noise: 0.001 + 0.009*rng.Float64()}
+		g.silence(200*time.Millisecond + g.between(0, 600*time.Millisecond))
+
+		phrases := 2 + rng.Intn(4)
+		distractor := -1
+		if rng.Float64() < 0.4 {
+			distractor = rng.Intn(phrases - 1)
+		}
+		for p := 0; p < phrases; p++ {
+			g.speech(g.between(800*time.Millisecond, 2500*time.Millisecond))
+			if p == phrases-1 {
+				break
+			}
+			pause := g.between(200*time.Millisecond, 1200*time.Millisecond)
+			if rng.Float64() < 0.15 {
+				// A long pause, e.g. before the callback number
+				pause = g.between(1500*time.Millisecond, 3*time.Second)
+			}
+			if p == distractor {
+				g.silence(pause / 2)
+				g.distractor()
+				g.silence(pause / 2)
+			} else {
+				g.silence(pause)
+			}
+		}
+
+		label := Label{File: filepath.Join(dir, fmt.Sprintf("synthetic_%03d.wav", i))}
+		if rng.Float64() < 0.6 {
+			g.silence(g.between(50*time.Millisecond, time.Second))
+			start := g.now()
+			g.tone(600+1200*rng.Float64(), g.between(120*time.Millisecond, 800*time.Millisecond), 0.03+0.27*rng.Float64())
+			label.Beeps = []Span{{Start: start, End: g.now()}}
+
+			// Some beeps are intermediate: the greeting carries on after them
+			if rng.Float64() < 0.25 {
+				g.silence(g.between(400*time.Millisecond, 1200*time.Millisecond))
+				g.speech(g.between(800*time.Millisecond, 2000*time.Millisecond))
+			}
+		}
+		label.GreetingEnd = g.now()
+		g.silence(4 * time.Second)
+
+		if err := audio.WriteWAV(label.File, g.samples, SyntheticSampleRate); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// greeting builds one synthetic recording over a constant noise floor
+type greeting struct {
+	rng     *rand.Rand
+	noise   float64
+	samples []float64
+}
+
+func (g *greeting) now() time.Duration {
+	return time.Duration(len(g.samples)) * time.Second / SyntheticSampleRate
+}
+
+func (g *greeting) between(lo, hi time.Duration) time.Duration {
+	return lo + time.Duration(g.rng.Int63n(int64(hi-lo)))
+}
+
+func (g *greeting) add(d time.Duration, sample func(t float64) float64) {
+	n := int(d.Seconds() * SyntheticSampleRate)
+	for i := 0; i < n; i++ {
+		g.samples = append(g.samples, sample(float64(i)/SyntheticSampleRate)+g.rng.NormFloat64()*g.noise)
+	}
+}
+
+func (g *greeting) silence(d time.Duration) {
+	g.add(d, func(float64) float64 { return 0 })
+}
+
+// speech is a voiced harmonic stack with a wandering pitch, modulated into
+// syllables
+func (g *greeting) speech(d time.Duration) {
+	base := 100 + 120*g.rng.Float64()
+	rate := 2 + 2*g.rng.Float64()
+	phase := 0.0
+	g.add(d, func(t float64) float64 {
+		phase += 2 * math.Pi * base * (1 + 0.12*math.Sin(2*math.Pi*3*t)) / SyntheticSampleRate
+		v := 0.0
+		for k := 1; k < 15; k++ {
+			v += 0.3 / float64(k) * math.Sin(float64(k)*phase)
+		}
+		return v * (0.2 + 0.8*math.Abs(math.Sin(math.Pi*rate*t))) * 0.4
+	})
+}
+
+func (g *greeting) tone(freq float64, d time.Duration, amp float64) {
+	g.add(d, func(t float64) float64 { return amp * math.Sin(2*math.Pi*freq*t) })
+}
+
+// distractor adds a tone that a beep detector may take for a beep
+func (g *greeting) distractor() {
+	switch g.rng.Intn(4) {
+	case 0:
+		// A few notes of music, each with its overtones
+		scale := []float64{523, 587, 659, 698, 784, 880, 988, 1047}
+		for n := 3 + g.rng.Intn(3); n > 0; n-- {
+			note := scale[g.rng.Intn(len(scale))]
+			g.add(g.between(150*time.Millisecond, 350*time.Millisecond), func(t float64) float64 {
+				v := 0.0
+				for k := 1; k < 5; k++ {
+					v += 0.15 / float64(k) * math.Sin(2*math.Pi*note*float64(k)*t)
+				}
+				return v * math.Exp(-3*t)
+			})
+		}
+	case 1:
+		// A DTMF digit
+		rows := []float64{697, 770, 852, 941}
+		cols := []float64{1209, 1336, 1477}
+		low, high := rows[g.rng.Intn(len(rows))], cols[g.rng.Intn(len(cols))]
+		g.add(g.between(80*time.Millisecond, 200*time.Millisecond), func(t float64) float64 {
+			return 0.1*math.Sin(2*math.Pi*low*t) + 0.1*math.Sin(2*math.Pi*high*t)
+		})
+	case 2:
+		// A rising chirp
+		from := 600 + 600*g.rng.Float64()
+		d := g.between(200*time.Millisecond, 500*time.Millisecond)
+		sweep := from / d.Seconds()
+		g.add(d, func(t float64) float64 { return 0.15 * math.Sin(2*math.Pi*(from*t+sweep*t*t/2)) })
+	case 3:
+		// A whistle: nearly pure, but with vibrato
+		freq := 800 + 800*g.rng.Float64()
+		phase := 0.0
+		g.add(g.between(250*time.Millisecond, 700*time.Millisecond), func(t float64) float64 {
+			phase += 2 * math.Pi * freq * (1 + 0.03*math.Sin(2*math.Pi*5*t)) / SyntheticSampleRate
+			return 0.15 * math.Sin(phase)
+		})
+	}
+}
@@ -11,22 +11,155 @@ func init() {
 	godotenv.Load()
 }
 
+// PlatformFingerprint describes a carrier voicemail platform by its beep and
+// canned system prompts
+type PlatformFingerprint struct {
+	Name            string
+	BeepFreq        float64 // Hz, 0 if the beep is not distinctive
+	BeepFreqTol     float64 // Hz
+	BeepMinDuration time.Duration
+	BeepMaxDuration time.Duration
+	Prompts         []string // matched case-insensitively against the transcript
+	Timing          TimingOverride
+}
+
+// TimingOverride replaces decision waits once a platform is identified. Zero
+// fields keep the configured default.
+type TimingOverride struct {
+	BeepWaitTimeout   time.Duration
+	PhraseSilenceWait time.Duration
+	ExpectsBeepWait   time.Duration
+}
+
 type Config struct {
 	// Audio processing settings
 	ChunkDuration time.Duration
 	SampleRate    int
 
+	// Pre-processing applied before all detectors
+	EnablePreprocessing bool
+	DCCutoffHz          float64 // DC-blocking high-pass corner
+	ClipLevel           float64 // |sample| at or above this counts as clipped
+	EnableAGC           bool
+	AGCTargetRMS        float64
+	AGCMaxGain          float64
+	AGCGateRMS          float64 // chunks quieter than this never raise the level estimate
+
+	// Echo cancellation against our outbound (reference) audio, when supplied
+	EchoTailLength      time.Duration // longest echo path the adaptive filter models
+	EchoStepSize        float64       // NLMS step size (0-1)
+	EchoSuppressionGain float64       // residual attenuation while only our audio plays
+
+	// Telephony filter stage for detector input
+	EnableBandPass  bool // 300-3400 Hz telephone band
+	EnableHumNotch  bool // auto-detect 50/60 Hz hum and notch its harmonics
+	HumDetectWindow time.Duration
+	HumMinAmplitude float64
+	FilterSTTInput  bool // also send the filtered audio to STT
+
 	// Beep detection settings
-	BeepMinFreq      float64
-	BeepMaxFreq      float64
-	BeepMinAmplitude float64
+	BeepMinFreq       float64
+	BeepMaxFreq       float64
+	BeepMinAmplitude  float64
+	BeepMinConfidence float64 // verified beeps scoring below this are ignored
+
+	// Beep sequences: beeps closer than DoubleBeepGap are one double beep, and
+	// a beep before any speech is verified for LeadingBeepVerify in case it is
+	// a system beep ahead of the personal greeting
+	DoubleBeepGap     time.Duration
+	LeadingBeepVerify time.Duration
+
+	// Pitch tracking to reject sung or sustained vowels as beeps
+	EnablePitchRejection    bool
+	BeepMaxHarmonicRichness float64 // share of power on other harmonics of the pitch
+	BeepVibratoThreshold    float64 // median relative pitch deviation across the tone
+
+	// Follow narrowband peaks across chunks so speech overlapping a beep doesn't hide it
+	EnablePeakTracking bool
+
+	// DTMF detection settings
+	EnableDTMF         bool
+	DTMFMinAmplitude   float64
+	DTMFMinDuration    time.Duration
+	DTMFNormalTwistDB  float64
+	DTMFReverseTwistDB float64
+
+	// Network tone classification (SIT, busy, reorder, ringback). Ringback is
+	// only terminal once it outlasts RingbackNoAnswer - a capture that starts
+	// before the call is answered hears a few rings first.
+	EnableNetworkTones      bool
+	NetworkToneMinAmplitude float64
+	RingbackNoAnswer        time.Duration
+
+	// Fax/modem tone detection (CNG, CED, ANSam)
+	EnableFaxDetection bool
+	FaxMinAmplitude    float64
+	CEDMinDuration     time.Duration
 
 	// Silence detection settings
 	SilenceThreshold float64
 	SilenceMinDur    time.Duration
 
+	// Silence confirmation: sustained silence needed to call the greeting over,
+	// and how short a silence must be for resumed speech to reset tracking
+	SilenceConfirmDur  time.Duration
+	SilenceResetDur    time.Duration
+	AdaptiveSilence    bool // learn the confirmation window from the speaker's pauses
+	AdaptiveSilenceMin time.Duration
+	AdaptiveSilenceMax time.Duration
+
+	// Adaptive noise floor: thresholds follow the line's background level
+	AdaptiveNoiseFloor  bool
+	NoiseFloorWindow    time.Duration
+	SilenceFloorRatio   float64 // silence threshold = noise floor * ratio
+	SpeechFloorRatio    float64 // speech threshold = noise floor * ratio
+	MinSilenceThreshold float64
+	MaxSilenceThreshold float64
+	MinSpeechThreshold  float64
+
+	// Voice activity detection on top of RMS
+	EnableVAD             bool
+	VADHangover           time.Duration
+	VADMaxZCR             float64
+	VADMaxFlatness        float64
+	VADMinSpeechBandRatio float64
+
+	// VoIP stream impairments (zero-filled packet loss, replayed frames, comfort noise)
+	EnableImpairmentDetection bool
+
+	// Music detection (music beds, hold music)
+	EnableMusicDetection bool
+	MusicWindow          time.Duration
+	MusicStopWait        time.Duration // quiet after music stops before treating it as greeting end
+
+	// Streams with no speech at all: how long to listen before classifying
+	// them, and the level the line must never reach to count as dead
+	NoSpeechTimeout time.Duration
+	DeadAirLevel    float64 // peak chunk RMS
+
+	// Decision policy, by name (see engine.PolicyNames)
+	DecisionPolicy string
+	RulesFile      string // rule file for the "rules" policy
+
+	// Fusion policy: starting "greeting ended" score before any evidence, and
+	// the score at which it drops. The threshold is picked by cmd/calibrate
+	// for a 5% early-drop rate on the labeled greetings; most evidence weights
+	// are hand-set, so the score itself is not a calibrated probability.
+	FusionPrior     float64
+	FusionThreshold float64
+
 	// Real-time streaming settings
-	BeepWaitTimeout time.Duration
+	BeepWaitTimeout   time.Duration
+	PhraseSilenceWait time.Duration // end phrase + silence, no beep expected
+	ExpectsBeepWait   time.Duration // phrase said "after the beep", wait this long for it
+	LatencyBudget     time.Duration // dead air we accept on confident evidence; waits scale with confidence, 0 = fixed waits
+	PostDropMonitor   time.Duration // keep listening this long after a drop for a late beep or resumed greeting, 0 = stop at the first decision
+
+	// Message fit: our prerecorded message against the mailbox's recording limit.
+	// Checks are off while either duration is 0.
+	MessageDuration           time.Duration
+	MaxRecordingLength        time.Duration
+	RecordingIncludesGreeting bool // the limit counts from call answer, not from the beep
 
 	// Speech-to-text settings
 	DeepgramAPIKey string
@@ -34,6 +167,28 @@ type Config struct {
 
 	// End phrase patterns
 	EndPhrases []string
+
+	// Prompts of mailboxes that cannot take a message - full or never set up
+	UnavailablePhrases []string
+
+	// Carrier voicemail platforms
+	EnablePlatformDetection bool
+	Platforms               []PlatformFingerprint
+}
+
+// WithTiming returns a copy of the config with the non-zero waits of t applied
+func (c *Config) WithTiming(t TimingOverride) *Config {
+	cfg := *c
+	if t.BeepWaitTimeout > 0 {
+		cfg.BeepWaitTimeout = t.BeepWaitTimeout
+	}
+	if t.PhraseSilenceWait > 0 {
+		cfg.PhraseSilenceWait = t.PhraseSilenceWait
+	}
+	if t.ExpectsBeepWait > 0 {
+		cfg.ExpectsBeepWait = t.ExpectsBeepWait
+	}
+	return &cfg
 }
 
 func DefaultConfig() *Config {
@@ -43,13 +198,98 @@ func DefaultConfig() *Config {
 		ChunkDuration: 20 * time.Millisecond,
 		SampleRate:    16000,
 
-		BeepMinFreq:      600.0,
-		BeepMaxFreq:      2500.0,
-		BeepMinAmplitude: 0.02,
+		EnablePreprocessing: true,
+		DCCutoffHz:          20.0,
+		ClipLevel:           0.99,
+		EnableAGC:           false,
+		AGCTargetRMS:        0.1,
+		AGCMaxGain:          10.0,
+		AGCGateRMS:          0.005,
+
+		EchoTailLength:      64 * time.Millisecond,
+		EchoStepSize:        0.5,
+		EchoSuppressionGain: 0.1,
+
+		EnableBandPass:  false,
+		EnableHumNotch:  true,
+		HumDetectWindow: 2 * time.Second,
+		HumMinAmplitude: 0.002,
+		FilterSTTInput:  false,
+
+		BeepMinFreq:       600.0,
+		BeepMaxFreq:       2500.0,
+		BeepMinAmplitude:  0.02,
+		BeepMinConfidence: 0.2,
+
+		DoubleBeepGap:     700 * time.Millisecond,
+		LeadingBeepVerify: 1500 * time.Millisecond,
+
+		EnablePitchRejection:    true,
+		BeepMaxHarmonicRichness: 0.25,
+		BeepVibratoThreshold:    0.01,
+
+		EnablePeakTracking: true,
+
+		EnableDTMF:         true,
+		DTMFMinAmplitude:   0.01,
+		DTMFMinDuration:    40 * time.Millisecond, // Q.24: accept >= 40ms
+		DTMFNormalTwistDB:  8.0,
+		DTMFReverseTwistDB: 4.0,
+
+		EnableNetworkTones:      true,
+		NetworkToneMinAmplitude: 0.01,
+		RingbackNoAnswer:        30 * time.Second,
+
+		EnableFaxDetection: true,
+		FaxMinAmplitude:    0.01,
+		CEDMinDuration:     1 * time.Second, // CED/ANS lasts 2.6-4s, beeps rarely exceed 1s
+
 		SilenceThreshold: 0.01,
 		SilenceMinDur:    500 * time.Millisecond,
 
-		BeepWaitTimeout: 2 * time.Second,
+		SilenceConfirmDur:  2 * time.Second,
+		SilenceResetDur:    2 * time.Second,
+		AdaptiveSilence:    false,
+		AdaptiveSilenceMin: 1 * time.Second,
+		AdaptiveSilenceMax: 6 * time.Second,
+
+		AdaptiveNoiseFloor:  true,
+		NoiseFloorWindow:    3 * time.Second,
+		SilenceFloorRatio:   2.0,
+		SpeechFloorRatio:    4.0,
+		MinSilenceThreshold: 0.01, // matches SilenceThreshold on clean lines
+		MaxSilenceThreshold: 0.05,
+		MinSpeechThreshold:  0.015,
+
+		EnableVAD:             true,
+		VADHangover:           200 * time.Millisecond,
+		VADMaxZCR:             0.35,
+		VADMaxFlatness:        0.5,
+		VADMinSpeechBandRatio: 0.4,
+
+		EnableImpairmentDetection: true,
+
+		EnableMusicDetection: true,
+		MusicWindow:          2 * time.Second,
+		MusicStopWait:        1 * time.Second,
+
+		NoSpeechTimeout: 10 * time.Second,
+		DeadAirLevel:    0.001,
+
+		DecisionPolicy: "priority",
+
+		FusionPrior:     0.05,
+		FusionThreshold: 0.97,
+
+		BeepWaitTimeout:   2 * time.Second,
+		PhraseSilenceWait: 1 * time.Second,
+		ExpectsBeepWait:   5 * time.Second,
+		LatencyBudget:     0,
+		PostDropMonitor:   0,
+
+		MessageDuration:           0,
+		MaxRecordingLength:        0,
+		RecordingIncludesGreeting: false,
 
 		DeepgramAPIKey: apiKey,
 		EnableSTT:      apiKey != "",
@@ -72,5 +312,44 @@ func DefaultConfig() *Config {
 			"record a message",
 			"your message after",
 		},
+
+		// A full or never set up mailbox says so instead of beeping
+		UnavailablePhrases: []string{
+			"mailbox is full",
+			"mailbox has not been set up",
+			"has not set up their voicemail",
+			"cannot accept any messages",
+			"is not accepting messages",
+		},
+
+		// Platforms are identified by prompt only. Beep figures are typical
+		// values that merely corroborate an identified platform.
+		EnablePlatformDetection: true,
+		Platforms: []PlatformFingerprint{
+			{
+				// Default greeting of carrier-hosted mailboxes that were never personalized
+				Name: "carrier_default",
+				Prompts: []string{
+					"forwarded to an automated voice messaging system",
+					"at the tone please record your message",
+					"when you have finished recording",
+				},
+				// The prompt always ends with "at the tone", and the tone follows promptly
+				Timing: TimingOverride{ExpectsBeepWait: 3 * time.Second},
+			},
+			{
+				// Prompts name the service, so no other system says them
+				Name:            "google_voice",
+				BeepFreq:        1000,
+				BeepFreqTol:     15,
+				BeepMinDuration: 350 * time.Millisecond,
+				BeepMaxDuration: 600 * time.Millisecond,
+				Prompts: []string{
+					"google subscriber you have called",
+					"google voice subscriber",
+				},
+				Timing: TimingOverride{ExpectsBeepWait: 3 * time.Second},
+			},
+		},
 	}
 }
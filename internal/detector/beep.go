@@ -13,6 +13,12 @@ type BeepEvent struct {
 	EndTime   time.Duration
 	Frequency float64
 	Amplitude float64
+
+	Confidence float64 // calibrated probability that this is a beep, updated while the trailing audio is observed
+	Score      BeepScore
+	Overlapped bool   // recovered by peak tracking while other audio overlapped the tone
+	NearMiss   bool   // tonal run that missed a hard threshold - reported, never acted on
+	MissReason string // why a near miss was rejected
 }
 
 type BeepDetector struct {
@@ -23,8 +29,32 @@ type BeepDetector struct {
 	beepFrequency   float64
 	beepAmplitude   float64
 	consecutiveHits int
+	bridged         int // chunks where overlapping audio hid the tone but its peak track held
+	bridging        int // consecutive bridged chunks
+	lastHitEnd      time.Duration
 	minHits         int
 	allBeeps        []*BeepEvent
+
+	pitch *PitchTracker
+	peaks peakTracker
+
+	// Spectrum of the latest chunk from analyzeForBeep
+	spectrum []float64
+	fftSize  int
+	freqRes  float64
+	minBin   int
+	maxBin   int
+	peakFine float64 // dominant peak frequency interpolated between bins
+
+	// Scoring state for the strict run
+	run      toneRun
+	recent   []float64 // RMS of recent chunks, for SNR against the audio before a tone
+	trailing []*trailingScore
+
+	// Looser tracking of tonal runs that may turn out to be near misses
+	loose        toneRun
+	looseActive  bool
+	looseHadBeep bool
 }
 
 func NewBeepDetector(cfg *config.Config, sampleRate int) *BeepDetector {
@@ -34,6 +64,7 @@ func NewBeepDetector(cfg *config.Config, sampleRate int) *BeepDetector {
 		config:     cfg,
 		sampleRate: sampleRate,
 		minHits:    minHits,
+		pitch:      NewPitchTracker(sampleRate),
 		allBeeps:   make([]*BeepEvent, 0),
 	}
 }
@@ -43,8 +74,12 @@ func (d *BeepDetector) Process(chunk audio.AudioChunk) *BeepEvent {
 		return nil
 	}
 
+	rms := calculateRMS(chunk.Samples)
+	d.observeTrailing(rms)
+
 	// Find dominant frequency and check if it's a tone-like signal
-	freq, amp, isTone := d.analyzeForBeep(chunk.Samples)
+	freq, amp, ratio := d.analyzeForBeep(chunk.Samples)
+	isTone := ratio > 5.0 // peak 5x above average (stricter than speech)
 
 	// Check if this matches beep criteria
 	isBeepLike := freq >= d.config.BeepMinFreq &&
@@ -52,6 +87,33 @@ func (d *BeepDetector) Process(chunk audio.AudioChunk) *BeepEvent {
 		amp >= d.config.BeepMinAmplitude &&
 		isTone
 
+	// DTMF digits are tonal in the beep band - a dual-tone pair is never a beep
+	isDTMF := d.config.EnableDTMF && IsDTMF(chunk.Samples, d.sampleRate, d.config)
+	if isBeepLike && isDTMF {
+		isBeepLike = false
+	}
+
+	var pitch *PitchEstimate
+	if isBeepLike && d.config.EnablePitchRejection {
+		estimate := d.pitch.Estimate(chunk.Samples, freq)
+		pitch = &estimate
+	} else {
+		d.pitch.Observe(chunk.Samples)
+	}
+
+	var endedTracks []*peakTrack
+	if d.config.EnablePeakTracking {
+		dominant := 0.0
+		if isBeepLike {
+			dominant = freq
+		}
+		peaks := findPeaks(d.spectrum, d.minBin, d.maxBin, d.fftSize, d.freqRes, d.config.BeepMinAmplitude*0.5)
+		endedTracks = d.peaks.update(peaks, chunk.Timestamp, chunk.Timestamp+chunk.Duration,
+			rms, d.precedingRMS(), dominant, d.freqRes, pitch)
+	}
+
+	nearMiss := d.trackLoose(chunk, freq, amp, ratio, rms, isDTMF)
+
 	// If we're already tracking a beep, check frequency consistency
 	// A real beep maintains a consistent frequency, speech won't
 	if isBeepLike && d.beepActive {
@@ -67,40 +129,253 @@ func (d *BeepDetector) Process(chunk audio.AudioChunk) *BeepEvent {
 			d.beepStartTime = chunk.Timestamp
 			d.beepFrequency = freq
 			d.beepAmplitude = amp
+			d.run = toneRun{preceding: d.precedingRMS()}
+
+			// Overlapping speech may have hidden the first chunks of the tone
+			// from the dominance check, but not from its peak track
+			if d.config.EnablePeakTracking {
+				if t := d.peaks.trackAt(freq, d.freqRes); t != nil &&
+					t.run.start < chunk.Timestamp && chunk.Timestamp-t.run.start <= maxBackdate {
+					d.beepStartTime = t.run.start
+					d.run.preceding = t.run.preceding
+				}
+			}
+		}
+		d.run.add(freq, ratio, rms)
+		if pitch != nil {
+			d.run.addPitch(*pitch)
 		}
+		d.run.peakFreqs = append(d.run.peakFreqs, d.peakFine)
 		d.consecutiveHits++
+		d.bridging = 0
+		d.lastHitEnd = chunk.Timestamp + chunk.Duration
 		d.beepActive = true
 		// Update running average of frequency/amplitude
 		d.beepFrequency = (d.beepFrequency*0.8 + freq*0.2) // Weighted average, favor existing
 		d.beepAmplitude = math.Max(d.beepAmplitude, amp)
+	} else if d.beepActive && d.toneContinues() {
+		// Speech covering the tone for a moment: the peak is still there, so
+		// the beep continues even though it no longer dominates the chunk
+		d.bridged++
+		d.bridging++
 	} else {
-		if d.beepActive && d.consecutiveHits >= d.minHits {
+		if d.beepActive && d.consecutiveHits+d.bridged >= d.minHits && d.consecutiveHits >= trackMinDominant {
 			event := &BeepEvent{
 				StartTime: d.beepStartTime,
-				EndTime:   chunk.Timestamp,
+				EndTime:   d.lastHitEnd, // bridged chunks at the tail are decay, not tone
 				Frequency: d.beepFrequency,
 				Amplitude: d.beepAmplitude,
 			}
-			d.allBeeps = append(d.allBeeps, event)
+			event.Score = d.run.score(event.EndTime - event.StartTime)
+			event.Confidence = event.Score.Confidence()
+			// Sung or sustained vowels pass the tone checks but carry a voice's
+			// harmonics and pitch movement - report them as near misses only
+			if reason := d.run.voiceLike(d.config); reason != "" {
+				event.NearMiss = true
+				event.MissReason = reason
+			} else {
+				d.allBeeps = append(d.allBeeps, event)
+			}
+			d.startTrailing(event)
+			d.looseHadBeep = true
 			d.reset()
+			d.pushRecent(rms)
 			return event
 		}
 		d.reset()
 	}
 
+	if event := d.overlappedBeep(endedTracks); event != nil {
+		d.pushRecent(rms)
+		d.startTrailing(event)
+		return event
+	}
+
+	d.pushRecent(rms)
+	if nearMiss != nil {
+		d.startTrailing(nearMiss)
+	}
+	return nearMiss
+}
+
+// overlappedBeep turns an ended peak track into a beep when the tone held
+// steady for long enough but, with speech on top of it, never dominated the
+// chunk for the full minimum duration the strict check needs
+func (d *BeepDetector) overlappedBeep(ended []*peakTrack) *BeepEvent {
+	if d.beepActive {
+		return nil
+	}
+
+	for _, t := range ended {
+		if !t.qualifiesAsBeep(d.minHits, d.config.BeepMinAmplitude) {
+			continue
+		}
+		// The strict path already reported this tone
+		if n := len(d.allBeeps); n > 0 && d.allBeeps[n-1].EndTime > t.run.start {
+			continue
+		}
+		if t.run.voiceLike(d.config) != "" {
+			continue
+		}
+
+		event := &BeepEvent{
+			StartTime:  t.run.start,
+			EndTime:    t.end,
+			Frequency:  medianOf(t.run.freqs),
+			Amplitude:  t.run.maxAmp,
+			Overlapped: true,
+		}
+		event.Score = t.run.score(event.EndTime - event.StartTime)
+		event.Confidence = event.Score.Confidence()
+		d.allBeeps = append(d.allBeeps, event)
+		return event
+	}
 	return nil
 }
 
+// trackLoose follows tonal runs under relaxed thresholds. A run that ends
+// without the strict detector having reported a beep inside it, but is still
+// steady and long enough to be interesting, comes back as a near miss.
+func (d *BeepDetector) trackLoose(chunk audio.AudioChunk, freq, amp, ratio, rms float64, isDTMF bool) *BeepEvent {
+	isCandidate := freq >= d.config.BeepMinFreq &&
+		freq <= d.config.BeepMaxFreq &&
+		amp >= d.config.BeepMinAmplitude*0.5 &&
+		ratio >= 3.0 &&
+		!isDTMF
+	if isCandidate && d.looseActive {
+		if math.Abs(freq-d.loose.lastFreq())/d.loose.lastFreq() > 0.15 {
+			isCandidate = false
+		}
+	}
+
+	if isCandidate {
+		if !d.looseActive {
+			d.looseActive = true
+			d.looseHadBeep = false
+			d.loose = toneRun{preceding: d.precedingRMS(), start: chunk.Timestamp}
+		}
+		d.loose.add(freq, ratio, rms)
+		d.loose.maxAmp = math.Max(d.loose.maxAmp, amp)
+		return nil
+	}
+
+	if !d.looseActive {
+		return nil
+	}
+	d.looseActive = false
+	if d.looseHadBeep || d.beepActive {
+		return nil
+	}
+	// A steady peak track at this frequency may still turn out to be an overlapped beep
+	if t := d.peaks.trackAt(d.loose.lastFreq(), d.freqRes); t != nil && t.qualifiesAsBeep(d.minHits, d.config.BeepMinAmplitude) {
+		return nil
+	}
+
+	duration := chunk.Timestamp - d.loose.start
+	if duration < nearMissMinDuration {
+		return nil
+	}
+	score := d.loose.score(duration)
+	if score.Stability < 0.5 || score.Weighted() < nearMissMinScore {
+		return nil
+	}
+
+	event := &BeepEvent{
+		StartTime:  d.loose.start,
+		EndTime:    chunk.Timestamp,
+		Frequency:  d.loose.meanFreq(),
+		Amplitude:  d.loose.maxAmp,
+		Score:      score,
+		Confidence: score.Confidence(),
+		NearMiss:   true,
+		MissReason: d.loose.missReason(d.minHits, d.config),
+	}
+	return event
+}
+
+// trailingScore follows the audio after one tone. A near miss can end while
+// the beep before it is still being scored, so each event keeps its own.
+type trailingScore struct {
+	event   *BeepEvent
+	toneRMS float64
+	chunks  int
+	quiet   int
+}
+
+// startTrailing begins scoring the audio after a tone; a beep is followed by
+// the caller's silence, a tonal peak in speech or music is not
+func (d *BeepDetector) startTrailing(event *BeepEvent) {
+	d.trailing = append(d.trailing, &trailingScore{event: event, toneRMS: event.Score.toneRMS})
+}
+
+func (d *BeepDetector) observeTrailing(rms float64) {
+	active := d.trailing[:0]
+	for _, t := range d.trailing {
+		quiet := math.Max(d.config.SilenceThreshold, t.toneRMS*0.1)
+		if rms < quiet {
+			t.quiet++
+		}
+		t.chunks++
+
+		t.event.Score.TrailingSilence = float64(t.quiet) / float64(t.chunks)
+		t.event.Confidence = t.event.Score.Confidence()
+
+		if time.Duration(t.chunks)*d.config.ChunkDuration < trailingWindow {
+			active = append(active, t)
+		}
+	}
+	d.trailing = active
+}
+
+func (d *BeepDetector) pushRecent(rms float64) {
+	d.recent = append(d.recent, rms)
+	if len(d.recent) > int(precedingWindow/d.config.ChunkDuration) {
+		d.recent = d.recent[1:]
+	}
+}
+
+func (d *BeepDetector) precedingRMS() float64 {
+	if len(d.recent) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, r := range d.recent {
+		sum += r * r
+	}
+	return math.Sqrt(sum / float64(len(d.recent)))
+}
+
+// Beeps returns every beep reported so far, in order. Near misses are not
+// included.
+func (d *BeepDetector) Beeps() []*BeepEvent {
+	return d.allBeeps
+}
+
+// IsTracking reports whether a tone is currently being tracked as a possible beep
+func (d *BeepDetector) IsTracking() bool {
+	return d.beepActive
+}
+
+// toneContinues reports whether the peak track under the active beep matched this chunk
+func (d *BeepDetector) toneContinues() bool {
+	if !d.config.EnablePeakTracking || d.bridging >= maxBridgeChunks {
+		return false
+	}
+	t := d.peaks.trackAt(d.beepFrequency, d.freqRes)
+	return t != nil && t.matched
+}
 
 func (d *BeepDetector) reset() {
 	d.beepActive = false
 	d.consecutiveHits = 0
+	d.bridged = 0
+	d.bridging = 0
 	d.beepFrequency = 0
 	d.beepAmplitude = 0
 }
 
-// FFT to find the dominant frequency and determine if it's a tone
-func (d *BeepDetector) analyzeForBeep(samples []float64) (float64, float64, bool) {
+// FFT to find the dominant frequency and how strongly the peak dominates the band
+func (d *BeepDetector) analyzeForBeep(samples []float64) (float64, float64, float64) {
 	// Pad to power of 2 for FFT efficiency
 	n := nextPowerOf2(len(samples))
 	if n < 128 {
@@ -132,8 +407,14 @@ func (d *BeepDetector) analyzeForBeep(samples []float64) (float64, float64, bool
 	var maxBinIdx int
 	var totalMag float64
 
+	d.spectrum = d.spectrum[:0]
+	for i := 0; i <= n/2; i++ {
+		d.spectrum = append(d.spectrum, cmplx.Abs(fft[i]))
+	}
+	d.fftSize, d.freqRes, d.minBin, d.maxBin = n, freqResolution, minBin, maxBin
+
 	for i := minBin; i <= maxBin; i++ {
-		mag := cmplx.Abs(fft[i])
+		mag := d.spectrum[i]
 		totalMag += mag
 		if mag > maxMag {
 			maxMag = mag
@@ -142,16 +423,23 @@ func (d *BeepDetector) analyzeForBeep(samples []float64) (float64, float64, bool
 	}
 
 	dominantFreq := float64(maxBinIdx) * freqResolution
+	d.peakFine = dominantFreq
+	if maxBinIdx > 0 && maxBinIdx+1 < len(d.spectrum) {
+		offset := parabolicOffset(d.spectrum[maxBinIdx-1], maxMag, d.spectrum[maxBinIdx+1])
+		d.peakFine = (float64(maxBinIdx) + offset) * freqResolution
+	}
 	// Normalize amplitude
 	amplitude := maxMag / float64(n) * 2
 
-	// Check if this is a pure tone by seeing if the peak dominates
+	// Check if this is a pure tone by seeing how much the peak dominates
 	// A beep should have most energy concentrated in a narrow band
-	// Require peak to be 5x above average (stricter than speech)
 	avgMag := totalMag / float64(maxBin-minBin+1)
-	isTone := maxMag > avgMag*5.0
+	ratio := 0.0
+	if avgMag > 0 {
+		ratio = maxMag / avgMag
+	}
 
-	return dominantFreq, amplitude, isTone
+	return dominantFreq, amplitude, ratio
 }
 
 func nextPowerOf2(n int) int {
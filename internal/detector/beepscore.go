@@ -0,0 +1,196 @@
+package detector
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"retape_ai/internal/config"
+)
+
+const (
+	nearMissMinDuration = 80 * time.Millisecond
+	nearMissMinScore    = 0.3
+	precedingWindow     = 500 * time.Millisecond
+	trailingWindow      = 300 * time.Millisecond
+	steadyDeviation     = 0.0025 // voice jitter alone moves a partial more than this
+)
+
+// BeepScore holds the 0-1 evidence components behind BeepEvent.Confidence
+type BeepScore struct {
+	Tonality        float64 // how far the spectral peak stands above the band average
+	Duration        float64 // 150ms scores 0.5, 300ms or longer scores 1
+	Stability       float64 // frequency steadiness across the tone
+	SNR             float64 // tone level against the audio just before it
+	TrailingSilence float64 // share of quiet chunks after the tone, 0.5 until observed
+
+	toneRMS float64
+}
+
+// Weighted combines the score components with hand-set weights. It ranks
+// candidates but is not a probability - Confidence is.
+func (s BeepScore) Weighted() float64 {
+	return 0.25*s.Tonality +
+		0.2*s.Duration +
+		0.2*s.Stability +
+		0.15*s.SNR +
+		0.2*s.TrailingSilence
+}
+
+// Confidence is the calibrated probability that the tone is a beep
+func (s BeepScore) Confidence() float64 {
+	return BeepCalibration.Apply(s.Weighted())
+}
+
+// Logistic maps a score onto a probability, 1 / (1 + exp(-(Slope*x + Bias)))
+type Logistic struct {
+	Slope float64
+	Bias  float64
+}
+
+func (l Logistic) Apply(x float64) float64 {
+	return 1 / (1 + math.Exp(-(l.Slope*x + l.Bias)))
+}
+
+// BeepCalibration turns the weighted score into Confidence. It is a logistic
+// fit to the tones the detector reports in labeled recordings, beeps and
+// otherwise: voicemails/labels.csv plus cmd/calibrate's synthetic greetings.
+// Rerun cmd/calibrate to refit it.
+var BeepCalibration = Logistic{Slope: 34.52, Bias: -27.36}
+
+// toneRun accumulates per-chunk measurements over one tonal run
+type toneRun struct {
+	start     time.Duration
+	freqs     []float64
+	ratioSum  float64
+	powerSum  float64
+	maxAmp    float64
+	preceding float64 // RMS of the audio before the run
+	pitches   []PitchEstimate
+	peakFreqs []float64 // interpolated dominant peak frequency per chunk
+}
+
+func (r *toneRun) add(freq, ratio, rms float64) {
+	r.freqs = append(r.freqs, freq)
+	r.ratioSum += ratio
+	r.powerSum += rms * rms
+}
+
+func (r *toneRun) addPitch(p PitchEstimate) {
+	r.pitches = append(r.pitches, p)
+}
+
+func (r *toneRun) lastFreq() float64 {
+	return r.freqs[len(r.freqs)-1]
+}
+
+func (r *toneRun) meanFreq() float64 {
+	var sum float64
+	for _, f := range r.freqs {
+		sum += f
+	}
+	return sum / float64(len(r.freqs))
+}
+
+func (r *toneRun) meanRatio() float64 {
+	return r.ratioSum / float64(len(r.freqs))
+}
+
+func (r *toneRun) score(duration time.Duration) BeepScore {
+	n := float64(len(r.freqs))
+	mean := r.meanFreq()
+
+	var variance float64
+	for _, f := range r.freqs {
+		variance += (f - mean) * (f - mean)
+	}
+	cv := 0.0
+	if mean > 0 {
+		cv = math.Sqrt(variance/n) / mean
+	}
+
+	toneRMS := math.Sqrt(r.powerSum / n)
+	snr := 1.0
+	if r.preceding > 0 {
+		snr = clamp01(20 * math.Log10(toneRMS/r.preceding) / 20)
+	}
+
+	return BeepScore{
+		Tonality:        clamp01((r.meanRatio() - 3) / 9),
+		Duration:        clamp01(duration.Seconds() / 0.3),
+		Stability:       clamp01(1 - cv/0.05),
+		SNR:             snr,
+		TrailingSilence: 0.5,
+		toneRMS:         toneRMS,
+	}
+}
+
+// missReason names the first hard threshold a near-miss run failed
+func (r *toneRun) missReason(minHits int, cfg *config.Config) string {
+	switch {
+	case len(r.freqs) < minHits:
+		return "too short"
+	case r.meanRatio() <= 5.0:
+		return "weak tonality"
+	case r.maxAmp < cfg.BeepMinAmplitude:
+		return "low amplitude"
+	}
+	return "unstable frequency"
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// voiceLike reports why the run looks like a sustained vowel or singing rather
+// than a beep, or "" if it doesn't. Voice spreads its energy over the
+// harmonics of a low pitch and that pitch wanders (vibrato, intonation);
+// a beep is a single partial and perfectly steady.
+func (r *toneRun) voiceLike(cfg *config.Config) string {
+	if len(r.pitches) == 0 {
+		return ""
+	}
+	// A partial this steady is a tone; harmonics around it belong to speech
+	// overlapping the beep rather than to the tone itself
+	if len(r.peakFreqs) >= 3 && relativeDeviation(r.peakFreqs) < steadyDeviation {
+		return ""
+	}
+
+	var rich int
+	for _, p := range r.pitches {
+		if p.Aligned && p.HarmonicRichness >= cfg.BeepMaxHarmonicRichness {
+			rich++
+		}
+	}
+	if float64(rich) >= float64(len(r.pitches))/2 {
+		return "voiced harmonics"
+	}
+
+	// Vibrato moves every harmonic; measured on the peak itself so speech
+	// overlapping a steady beep doesn't count
+	if len(r.peakFreqs) >= 3 && relativeDeviation(r.peakFreqs) >= cfg.BeepVibratoThreshold {
+		return "pitch vibrato"
+	}
+	return ""
+}
+
+// relativeDeviation is the median absolute deviation over the median, so an
+// onset chunk locking onto the wrong period doesn't read as vibrato
+func relativeDeviation(values []float64) float64 {
+	median := medianOf(values)
+	if median == 0 {
+		return 0
+	}
+
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+	}
+	return medianOf(deviations) / median
+}
+
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/2]
+}
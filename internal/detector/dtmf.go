@@ -0,0 +1,137 @@
+package detector
+
+import (
+	"math"
+	"time"
+
+	"retape_ai/internal/audio"
+	"retape_ai/internal/config"
+)
+
+var (
+	dtmfRowFreqs = [4]float64{697, 770, 852, 941}
+	dtmfColFreqs = [4]float64{1209, 1336, 1477, 1633}
+	dtmfDigits   = [4][4]byte{
+		{'1', '2', '3', 'A'},
+		{'4', '5', '6', 'B'},
+		{'7', '8', '9', 'C'},
+		{'*', '0', '#', 'D'},
+	}
+)
+
+type DTMFEvent struct {
+	Digit     byte
+	StartTime time.Duration
+	EndTime   time.Duration
+}
+
+// DTMFDetector detects DTMF digits using ITU-T Q.24 style checks:
+// one row and one column tone present, each dominating its group,
+// twist within limits, and a minimum tone duration
+type DTMFDetector struct {
+	config     *config.Config
+	sampleRate int
+
+	active    bool
+	digit     byte
+	startTime time.Duration
+}
+
+func NewDTMFDetector(cfg *config.Config, sampleRate int) *DTMFDetector {
+	return &DTMFDetector{
+		config:     cfg,
+		sampleRate: sampleRate,
+	}
+}
+
+func (d *DTMFDetector) Process(chunk audio.AudioChunk) *DTMFEvent {
+	if !d.config.EnableDTMF || len(chunk.Samples) < 64 {
+		return nil
+	}
+
+	digit, ok := analyzeDTMF(chunk.Samples, d.sampleRate, d.config)
+
+	// Same digit still sounding - keep accumulating duration
+	if ok && d.active && digit == d.digit {
+		return nil
+	}
+
+	var event *DTMFEvent
+	if d.active && chunk.Timestamp-d.startTime >= d.config.DTMFMinDuration {
+		event = &DTMFEvent{
+			Digit:     d.digit,
+			StartTime: d.startTime,
+			EndTime:   chunk.Timestamp,
+		}
+	}
+
+	d.active = ok
+	if ok {
+		d.digit = digit
+		d.startTime = chunk.Timestamp
+	}
+
+	return event
+}
+
+// IsDTMF reports whether the samples contain a valid dual-tone DTMF pair.
+// Used by other detectors to discount DTMF energy.
+func IsDTMF(samples []float64, sampleRate int, cfg *config.Config) bool {
+	_, ok := analyzeDTMF(samples, sampleRate, cfg)
+	return ok
+}
+
+func analyzeDTMF(samples []float64, sampleRate int, cfg *config.Config) (byte, bool) {
+	var rowAmps, colAmps [4]float64
+	for i := 0; i < 4; i++ {
+		rowAmps[i] = goertzelAmplitude(samples, dtmfRowFreqs[i], sampleRate)
+		colAmps[i] = goertzelAmplitude(samples, dtmfColFreqs[i], sampleRate)
+	}
+
+	row, rowAmp := strongest(rowAmps)
+	col, colAmp := strongest(colAmps)
+
+	if rowAmp < cfg.DTMFMinAmplitude || colAmp < cfg.DTMFMinAmplitude {
+		return 0, false
+	}
+
+	// Each tone must clearly dominate the other tones in its group (6 dB)
+	for i := 0; i < 4; i++ {
+		if i != row && rowAmps[i]*2 > rowAmp {
+			return 0, false
+		}
+		if i != col && colAmps[i]*2 > colAmp {
+			return 0, false
+		}
+	}
+
+	// Twist: normal = column (high group) louder, reverse = row louder
+	twistDB := 20 * math.Log10(colAmp/rowAmp)
+	if twistDB > cfg.DTMFNormalTwistDB || -twistDB > cfg.DTMFReverseTwistDB {
+		return 0, false
+	}
+
+	// The two tones must carry most of the chunk's energy
+	rms := calculateRMS(samples)
+	tonePower := (rowAmp*rowAmp + colAmp*colAmp) / 2
+	if tonePower < 0.6*rms*rms {
+		return 0, false
+	}
+
+	// Voiced speech has strong harmonics, DTMF does not
+	if goertzelAmplitude(samples, 2*dtmfRowFreqs[row], sampleRate) > rowAmp/4 {
+		return 0, false
+	}
+
+	return dtmfDigits[row][col], true
+}
+
+func strongest(amps [4]float64) (int, float64) {
+	idx := 0
+	for i := 1; i < len(amps); i++ {
+		if amps[i] > amps[idx] {
+			idx = i
+		}
+	}
+	return idx, amps[idx]
+}
@@ -0,0 +1,56 @@
+package detector
+
+import (
+	"testing"
+
+	"retape_ai/internal/config"
+)
+
+// digits reports every DTMF digit the detector finds in s
+func digits(s signal) string {
+	d := NewDTMFDetector(config.DefaultConfig(), testSampleRate)
+	var found []byte
+	for _, chunk := range s.chunks() {
+		if event := d.Process(chunk); event != nil {
+			found = append(found, event.Digit)
+		}
+	}
+	return string(found)
+}
+
+func TestDTMFDigits(t *testing.T) {
+	for row, rowFreq := range dtmfRowFreqs {
+		for col, colFreq := range dtmfColFreqs {
+			want := string(dtmfDigits[row][col])
+			t.Run(want, func(t *testing.T) {
+				s := signal(nil).silence(ms(60)).tone(ms(100), 0.1, rowFreq, colFreq).silence(ms(60))
+				if got := digits(s); got != want {
+					t.Fatalf("expected %q, got %q", want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestDTMFRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		s    signal
+	}{
+		{"single tone", signal(nil).tone(ms(100), 0.1, 697)},
+		{"too short", signal(nil).tone(ms(20), 0.1, 697, 1209)},
+		{"too quiet", signal(nil).tone(ms(100), 0.005, 697, 1209)},
+		{"row far louder than column", signal(nil).tone(ms(100), 0.1, 697).plus(signal(nil).tone(ms(100), 0.03, 1209))},
+		{"two rows", signal(nil).tone(ms(100), 0.1, 697, 852)},
+		{"beep", signal(nil).tone(ms(300), 0.2, 1000)},
+		{"speech", signal(nil).speech(ms(500))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := digits(tt.s.silence(ms(60))); got != "" {
+				t.Fatalf("expected no digits, got %q", got)
+			}
+		})
+	}
+}
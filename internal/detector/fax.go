@@ -0,0 +1,298 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+	"time"
+
+	"retape_ai/internal/audio"
+	"retape_ai/internal/config"
+)
+
+// Fax/modem tone types reported in FaxToneEvent.Type
+const (
+	ToneFaxCNG  = "cng"      // calling fax: 1100 Hz, 0.5s on / 3s off
+	ToneCED     = "ced"      // answering fax / V.25 ANS: plain 2100 Hz
+	ToneANSPR   = "ans/pr"   // 2100 Hz with phase reversals every 450ms
+	ToneANSam   = "ansam"    // V.8 ANSam: 2100 Hz with 15 Hz amplitude modulation
+	ToneANSamPR = "ansam/pr" // ANSam with phase reversals
+)
+
+const (
+	cngFreq      = 1100.0
+	cedFreq      = 2100.0
+	cngMinBurst  = 400 * time.Millisecond
+	cngMaxBurst  = 650 * time.Millisecond
+	cngMinOff    = 2500 * time.Millisecond
+	cngMaxOff    = 3500 * time.Millisecond
+	ansamMinMod  = 0.05 // std/mean of per-chunk envelope
+	reversalGate = 2 * math.Pi / 3
+)
+
+type FaxToneEvent struct {
+	Type      string
+	StartTime time.Duration
+	EndTime   time.Duration
+	Details   string
+}
+
+// FaxToneDetector recognizes fax and modem answer/calling tones. These sit inside
+// the beep band (1100 Hz CNG, 2100 Hz CED/ANSam) and would otherwise look like
+// a voicemail beep.
+type FaxToneDetector struct {
+	config     *config.Config
+	sampleRate int
+	detected   *FaxToneEvent
+
+	// CNG burst cadence tracking
+	cngActive     bool
+	cngStart      time.Duration
+	lastCNGEnd    time.Duration
+	lastCNGBurst  time.Duration
+	cngInterrupts bool
+
+	// CED/ANS tracking
+	cedActive     bool
+	cedStart      time.Duration
+	cedDipped     bool
+	phases        []float64 // 2100 Hz phase per chunk of the active segment
+	envelope      []float64 // 2100 Hz amplitude per chunk of the active segment
+	phaseDrift    float64
+	reversals     int
+	skipReversal  bool
+	lastChunkTime time.Duration
+}
+
+func NewFaxToneDetector(cfg *config.Config, sampleRate int) *FaxToneDetector {
+	return &FaxToneDetector{
+		config:     cfg,
+		sampleRate: sampleRate,
+	}
+}
+
+func (d *FaxToneDetector) Process(chunk audio.AudioChunk) *FaxToneEvent {
+	if !d.config.EnableFaxDetection || d.detected != nil || len(chunk.Samples) < 64 {
+		return nil
+	}
+
+	d.lastChunkTime = chunk.Timestamp + chunk.Duration
+
+	rms := calculateRMS(chunk.Samples)
+	power := rms * rms
+
+	cng := goertzel(chunk.Samples, cngFreq, d.sampleRate)
+	ced := goertzel(chunk.Samples, cedFreq, d.sampleRate)
+	norm := 2 / float64(len(chunk.Samples))
+	cngAmp := cmplx.Abs(cng) * norm
+	cedAmp := cmplx.Abs(ced) * norm
+
+	isCNG := cngAmp >= d.config.FaxMinAmplitude && cngAmp*cngAmp/2 >= 0.6*power
+	// Phase reversals dip the amplitude of the straddling chunk, so CED uses a looser energy check
+	isCED := cedAmp >= d.config.FaxMinAmplitude && cedAmp*cedAmp/2 >= 0.3*power
+
+	if event := d.trackCED(chunk, isCED, ced, cedAmp); event != nil {
+		d.detected = event
+		return event
+	}
+
+	if event := d.trackCNG(chunk, isCNG, rms); event != nil {
+		d.detected = event
+		return event
+	}
+
+	return nil
+}
+
+func (d *FaxToneDetector) trackCED(chunk audio.AudioChunk, isCED bool, bin complex128, amp float64) *FaxToneEvent {
+	// A phase reversal mid-chunk cancels most of that chunk's 2100 Hz energy,
+	// so a single dipped chunk does not end the segment
+	if !isCED && d.cedActive && !d.cedDipped && amp > 0 {
+		d.cedDipped = true
+	} else if !isCED {
+		d.cedActive = false
+		d.cedDipped = false
+		d.phases = d.phases[:0]
+		d.envelope = d.envelope[:0]
+		d.reversals = 0
+		d.phaseDrift = 0
+		d.skipReversal = false
+		return nil
+	}
+
+	if !d.cedActive {
+		d.cedActive = true
+		d.cedStart = chunk.Timestamp
+	}
+	if isCED {
+		d.cedDipped = false
+	}
+
+	d.phases = append(d.phases, cmplx.Phase(bin))
+	d.envelope = append(d.envelope, amp)
+	d.detectReversal(len(chunk.Samples))
+
+	duration := d.lastChunkTime - d.cedStart
+	if duration < d.config.CEDMinDuration {
+		return nil
+	}
+
+	modulation := envelopeModulation(d.envelope)
+	am := modulation >= ansamMinMod
+	toneType := ToneCED
+	switch {
+	case am && d.reversals > 0:
+		toneType = ToneANSamPR
+	case am:
+		toneType = ToneANSam
+	case d.reversals > 0:
+		toneType = ToneANSPR
+	}
+
+	return &FaxToneEvent{
+		Type:      toneType,
+		StartTime: d.cedStart,
+		EndTime:   d.lastChunkTime,
+		Details:   fmt.Sprintf("2100 Hz, reversals=%d, am=%.2f", d.reversals, modulation),
+	}
+}
+
+// detectReversal looks for a ~180° phase jump against the expected per-chunk
+// phase advance. A reversal inside a chunk smears over two chunk steps, so the
+// phase is compared across two chunks and the following step is skipped.
+func (d *FaxToneDetector) detectReversal(chunkLen int) {
+	n := len(d.phases)
+	if n < 2 {
+		return
+	}
+
+	advance := 2 * math.Pi * cedFreq * float64(chunkLen) / float64(d.sampleRate)
+	step := wrapPhase(d.phases[n-1] - d.phases[n-2] - advance)
+
+	// Track slow drift from the tone being a few Hz off 2100
+	if n == 2 {
+		d.phaseDrift = step
+	} else if math.Abs(wrapPhase(step-d.phaseDrift)) < math.Pi/4 {
+		d.phaseDrift = wrapPhase(d.phaseDrift + 0.3*wrapPhase(step-d.phaseDrift))
+	}
+
+	if n < 3 {
+		return
+	}
+	if d.skipReversal {
+		d.skipReversal = false
+		return
+	}
+
+	twoStep := wrapPhase(d.phases[n-1] - d.phases[n-3] - 2*advance - 2*d.phaseDrift)
+	if math.Abs(twoStep) > reversalGate {
+		d.reversals++
+		d.skipReversal = true
+	}
+}
+
+func (d *FaxToneDetector) trackCNG(chunk audio.AudioChunk, isCNG bool, rms float64) *FaxToneEvent {
+	if isCNG {
+		if !d.cngActive {
+			d.cngActive = true
+			d.cngStart = chunk.Timestamp
+		}
+		return nil
+	}
+
+	if d.cngActive {
+		d.cngActive = false
+		burst := chunk.Timestamp - d.cngStart
+		if burst < cngMinBurst || burst > cngMaxBurst {
+			d.lastCNGEnd = 0
+			return nil
+		}
+
+		// Second burst with the right off period confirms CNG
+		if d.lastCNGEnd > 0 && !d.cngInterrupts {
+			off := d.cngStart - d.lastCNGEnd
+			if off >= cngMinOff && off <= cngMaxOff {
+				return &FaxToneEvent{
+					Type:      ToneFaxCNG,
+					StartTime: d.cngStart - off - d.lastCNGBurst,
+					EndTime:   chunk.Timestamp,
+					Details:   fmt.Sprintf("1100 Hz, on=%v off=%v", burst, off),
+				}
+			}
+		}
+
+		d.lastCNGEnd = chunk.Timestamp
+		d.lastCNGBurst = burst
+		d.cngInterrupts = false
+		return nil
+	}
+
+	// Anything louder than line noise between bursts rules out CNG cadence
+	if d.lastCNGEnd > 0 && rms >= d.config.SilenceThreshold*3 {
+		d.cngInterrupts = true
+	}
+
+	return nil
+}
+
+// Pending reports whether a fax/modem tone may be in progress, so callers can
+// hold off treating it as a voicemail beep. After a single CNG-length burst it
+// stays true through the cadence's off period, since one burst can't tell CNG
+// from a beep - only other evidence of a mailbox, such as a greeting, should
+// take precedence over that.
+func (d *FaxToneDetector) Pending() bool {
+	if !d.config.EnableFaxDetection || d.detected != nil {
+		return false
+	}
+	if d.cedActive || d.cngActive {
+		return true
+	}
+	return d.lastCNGEnd > 0 && !d.cngInterrupts && d.lastChunkTime-d.lastCNGEnd <= cngMaxOff
+}
+
+func (d *FaxToneDetector) Detected() *FaxToneEvent {
+	return d.detected
+}
+
+func envelopeModulation(envelope []float64) float64 {
+	if len(envelope) < 2 {
+		return 0
+	}
+
+	// Chunks dipped by a phase reversal are excluded using the median as reference
+	sorted := append([]float64(nil), envelope...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	var sum, sumSq float64
+	var count int
+	for _, v := range envelope {
+		if v < median*0.6 {
+			continue
+		}
+		sum += v
+		sumSq += v * v
+		count++
+	}
+	if count < 2 || sum == 0 {
+		return 0
+	}
+
+	mean := sum / float64(count)
+	variance := sumSq/float64(count) - mean*mean
+	if variance < 0 {
+		variance = 0
+	}
+	return math.Sqrt(variance) / mean
+}
+
+func wrapPhase(p float64) float64 {
+	for p > math.Pi {
+		p -= 2 * math.Pi
+	}
+	for p < -math.Pi {
+		p += 2 * math.Pi
+	}
+	return p
+}
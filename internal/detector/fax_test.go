@@ -0,0 +1,73 @@
+package detector
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"retape_ai/internal/config"
+)
+
+// faxTone runs s through a fresh fax detector and returns it with the tone it
+// detected, "" if none
+func faxTone(s signal) (*FaxToneDetector, string) {
+	d := NewFaxToneDetector(config.DefaultConfig(), testSampleRate)
+	for _, chunk := range s.chunks() {
+		if event := d.Process(chunk); event != nil {
+			return d, event.Type
+		}
+	}
+	return d, ""
+}
+
+func TestFaxTones(t *testing.T) {
+	steady := func(float64) float64 { return 0.2 }
+	noPhase := func(float64) float64 { return 0 }
+	am := func(t float64) float64 { return 0.2 * (1 + 0.2*math.Sin(2*math.Pi*15*t)) }
+	reversals := func(t float64) float64 { return math.Pi * math.Floor(t/0.45) }
+
+	tests := []struct {
+		name string
+		s    signal
+		want string
+	}{
+		{"CNG", signal(nil).cadence(8*time.Second, ms(500), 3*time.Second, 0.1, cngFreq), ToneFaxCNG},
+		{"single CNG burst", signal(nil).tone(ms(500), 0.1, cngFreq).silence(4 * time.Second), ""},
+		{"1100 Hz at beep cadence", signal(nil).cadence(4*time.Second, ms(500), ms(500), 0.1, cngFreq), ""},
+		{"CNG with speech between bursts", signal(nil).tone(ms(500), 0.1, cngFreq).speech(3*time.Second).tone(ms(500), 0.1, cngFreq).silence(ms(200)), ""},
+		{"CED", signal(nil).shaped(3*time.Second, cedFreq, steady, noPhase), ToneCED},
+		{"ANSam", signal(nil).shaped(3*time.Second, cedFreq, am, noPhase), ToneANSam},
+		{"ANS/PR", signal(nil).shaped(3*time.Second, cedFreq, steady, reversals), ToneANSPR},
+		{"ANSam/PR", signal(nil).shaped(3*time.Second, cedFreq, am, reversals), ToneANSamPR},
+		{"short 2100 Hz beep", signal(nil).tone(ms(500), 0.2, cedFreq).silence(ms(500)), ""},
+		{"1000 Hz beep", signal(nil).tone(ms(500), 0.2, 1000).silence(ms(500)), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := faxTone(tt.s); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFaxPendingThroughCNGOffPeriod(t *testing.T) {
+	tests := []struct {
+		after   time.Duration
+		pending bool
+	}{
+		{ms(100), true},
+		{2 * time.Second, true},
+		{4 * time.Second, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.after.String(), func(t *testing.T) {
+			d, _ := faxTone(signal(nil).tone(ms(500), 0.1, cngFreq).silence(tt.after))
+			if d.Pending() != tt.pending {
+				t.Fatalf("expected Pending %v %v after a CNG-length burst", tt.pending, tt.after)
+			}
+		})
+	}
+}
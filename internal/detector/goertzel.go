@@ -0,0 +1,39 @@
+package detector
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// goertzel computes the single DFT term at freq using the Goertzel algorithm.
+// Unlike the FFT this works for arbitrary (non-bin-centered) frequencies and is
+// much cheaper when only a handful of frequencies are of interest.
+func goertzel(samples []float64, freq float64, sampleRate int) complex128 {
+	n := len(samples)
+	if n == 0 {
+		return 0
+	}
+
+	w := 2 * math.Pi * freq / float64(sampleRate)
+	coeff := 2 * math.Cos(w)
+
+	var s1, s2 float64
+	for _, x := range samples {
+		s0 := x + coeff*s1 - s2
+		s2 = s1
+		s1 = s0
+	}
+
+	// y = s[N-1] - e^{-jw} s[N-2], rotated back so the phase is relative to sample 0
+	y := complex(s1, 0) - cmplx.Exp(complex(0, -w))*complex(s2, 0)
+	return y * cmplx.Exp(complex(0, -w*float64(n-1)))
+}
+
+// goertzelAmplitude returns the amplitude of a sinusoid at freq, normalized so a
+// full-scale sine of amplitude A reports ~A
+func goertzelAmplitude(samples []float64, freq float64, sampleRate int) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	return 2 * cmplx.Abs(goertzel(samples, freq, sampleRate)) / float64(len(samples))
+}
@@ -0,0 +1,35 @@
+package detector
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGoertzelAmplitude(t *testing.T) {
+	tests := []struct {
+		name  string
+		tone  float64
+		amp   float64
+		probe float64
+		want  float64
+		tol   float64 // share of amp
+	}{
+		{"on bin", 1000, 0.5, 1000, 0.5, 0.05},
+		{"between bins", 941, 0.2, 941, 0.2, 0.05},
+		{"SIT low", 913.8, 0.1, 913.8, 0.1, 0.05},
+		{"CED", 2100, 0.3, 2100, 0.3, 0.05},
+		{"other frequency", 1000, 0.5, 1400, 0, 0.05},
+		// A 20ms window leaks into the next row, but not past DTMF's 6 dB dominance check
+		{"neighbouring DTMF row", 697, 0.2, 770, 0, 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := signal(nil).tone(testChunk, tt.amp, tt.tone)
+			got := goertzelAmplitude(samples, tt.probe, testSampleRate)
+			if math.Abs(got-tt.want) > tt.tol*tt.amp {
+				t.Fatalf("expected amplitude %.3f at %.0f Hz, got %.3f", tt.want, tt.probe, got)
+			}
+		})
+	}
+}
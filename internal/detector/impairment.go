@@ -0,0 +1,260 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"retape_ai/internal/audio"
+	"retape_ai/internal/config"
+)
+
+// Stream impairment types reported in ImpairmentEvent.Type
+const (
+	ImpairmentZeroGap       = "zero_gap"       // packet loss filled with digital zeros
+	ImpairmentRepeatedFrame = "repeated_frame" // packet loss concealed by replaying a frame
+	ImpairmentComfortNoise  = "comfort_noise"  // VoIP comfort noise standing in for silence
+)
+
+const (
+	zeroSampleLevel    = 1e-6
+	minZeroRun         = 5 * time.Millisecond
+	maxZeroGap         = 250 * time.Millisecond // longer digital silence is the line, not packet loss
+	comfortNoiseFrames = 10                     // chunks of history for the level stationarity check
+	comfortNoiseMaxCV  = 0.1                    // CN is re-generated from rare SID frames, so its level barely moves
+	comfortNoiseFlat   = 0.35                   // minimum spectral flatness
+	toneMaxFlatness    = 0.005                  // a steady generated tone is this far from flat...
+	toneMinPeak        = 0.85                   // ...with its energy in a few bins
+	impairmentEpisode  = time.Second
+)
+
+type ImpairmentEvent struct {
+	Type      string
+	StartTime time.Duration
+	Details   string
+}
+
+// ImpairmentDetector recognizes VoIP stream artifacts that carry no greeting
+// content - zero-filled packet loss, replayed frames, comfort noise - so the
+// silence detector can treat them as silence-compatible instead of flipping
+// state on them.
+type ImpairmentDetector struct {
+	config     *config.Config
+	sampleRate int
+
+	history  []float64 // the previous two chunks, for frame-aligned repeats
+	levels   []float64
+	zeroRun  int  // digital zeros carried over from previous chunks
+	hadAudio bool // non-zero audio seen before the current zero run
+	hadSound bool // a non-silent segment came before the current quiet one
+	lastSeen map[string]time.Duration
+	counts   map[string]int
+	current  string
+
+	// Result of Inspect, consumed by the next Process
+	pending        string
+	pendingStart   time.Duration
+	pendingDetails string
+}
+
+func NewImpairmentDetector(cfg *config.Config, sampleRate int) *ImpairmentDetector {
+	return &ImpairmentDetector{
+		config:     cfg,
+		sampleRate: sampleRate,
+		lastSeen:   make(map[string]time.Duration),
+		counts:     make(map[string]int),
+	}
+}
+
+// Inspect runs the sample-exact packet loss checks. It must see the chunk as
+// received: the DC blocker and filters smear digital zeros and replayed frames
+// into ordinary-looking audio.
+func (d *ImpairmentDetector) Inspect(chunk audio.AudioChunk) {
+	d.pending, d.pendingStart, d.pendingDetails = "", chunk.Timestamp, ""
+	if !d.config.EnableImpairmentDetection || len(chunk.Samples) == 0 {
+		return
+	}
+
+	if gap, offset := d.zeroGap(chunk.Samples); gap > 0 {
+		d.pending = ImpairmentZeroGap
+		d.pendingStart = chunk.Timestamp + offset - gap
+		d.pendingDetails = fmt.Sprintf("%v zero-filled gap", gap)
+	} else if d.isRepeatedFrame(chunk.Samples) {
+		d.pending = ImpairmentRepeatedFrame
+		d.pendingDetails = "frame replayed by packet loss concealment"
+	}
+	d.history = append(d.history, chunk.Samples...)
+	if keep := 2 * len(chunk.Samples); len(d.history) > keep {
+		d.history = d.history[len(d.history)-keep:]
+	}
+}
+
+// Process classifies one chunk, combining what Inspect found on the raw chunk
+// with a comfort noise check on the processed one. features are its VAD
+// features, and silenceLevel and speechLevel the RMS levels below which it
+// is silent and above which it would count as speech. An event is returned
+// at the start of each impairment episode.
+func (d *ImpairmentDetector) Process(chunk audio.AudioChunk, features VADFeatures, silenceLevel, speechLevel float64) *ImpairmentEvent {
+	start, details := d.pendingStart, d.pendingDetails
+	d.current, d.pending = d.pending, ""
+	if !d.config.EnableImpairmentDetection || len(chunk.Samples) == 0 {
+		d.current = ""
+		return nil
+	}
+
+	// A digitally generated tone (CED, a beep) repeats every frame as well -
+	// replayed frames are only believable in audio that isn't a steady tone
+	if d.current == ImpairmentRepeatedFrame &&
+		features.SpectralFlatness < toneMaxFlatness && features.PeakConcentration > toneMinPeak {
+		d.current = ""
+	}
+
+	rms := calculateRMS(chunk.Samples)
+	d.levels = append(d.levels, rms)
+	if len(d.levels) > comfortNoiseFrames {
+		d.levels = d.levels[1:]
+	}
+	if rms >= speechLevel {
+		d.hadSound = true
+	}
+
+	if d.current == "" && d.isComfortNoise(rms, features.SpectralFlatness, silenceLevel, speechLevel) {
+		d.current = ImpairmentComfortNoise
+		start = chunk.Timestamp
+		details = fmt.Sprintf("stationary noise at RMS %.4f", rms)
+	}
+	if d.current == "" {
+		return nil
+	}
+
+	d.counts[d.current]++
+	last, seen := d.lastSeen[d.current]
+	d.lastSeen[d.current] = chunk.Timestamp + chunk.Duration
+	if seen && chunk.Timestamp-last < impairmentEpisode {
+		return nil
+	}
+	return &ImpairmentEvent{Type: d.current, StartTime: start, Details: details}
+}
+
+// Current returns the impairment type of the last processed chunk, or ""
+func (d *ImpairmentDetector) Current() string {
+	return d.current
+}
+
+// SilenceCompatible reports whether the last chunk was an artifact that
+// should neither count as speech nor break a silence period
+func (d *ImpairmentDetector) SilenceCompatible() bool {
+	return d.current != ""
+}
+
+// Counts returns how many chunks of each impairment type were seen
+func (d *ImpairmentDetector) Counts() map[string]int {
+	return d.counts
+}
+
+// zeroGap returns the length of a run of digital zeros between two stretches
+// of audio that ended in this chunk, and the offset where audio resumed. A gap
+// is only known once audio resumes; a line that stays at digital zero is just
+// silence.
+func (d *ImpairmentDetector) zeroGap(samples []float64) (time.Duration, time.Duration) {
+	minRun := int(minZeroRun.Seconds() * float64(d.sampleRate))
+	maxRun := int(maxZeroGap.Seconds() * float64(d.sampleRate))
+
+	var gap, offset time.Duration
+	for i, s := range samples {
+		if math.Abs(s) < zeroSampleLevel {
+			d.zeroRun++
+			continue
+		}
+		if d.hadAudio && d.zeroRun >= minRun && d.zeroRun <= maxRun {
+			gap = d.samplesToDuration(d.zeroRun)
+			offset = d.samplesToDuration(i)
+		}
+		d.zeroRun = 0
+		d.hadAudio = true
+	}
+	return gap, offset
+}
+
+func (d *ImpairmentDetector) samplesToDuration(n int) time.Duration {
+	return time.Duration(n) * time.Second / time.Duration(d.sampleRate)
+}
+
+// isRepeatedFrame looks for a codec frame (10 or 20ms, aligned to the chunk)
+// that is a sample-exact copy of the frame before it, where that frame was
+// fresh audio rather than a copy of its own predecessor. Real audio never
+// repeats exactly; digitally generated tones do, but they were already
+// repeating before and also repeat at their own shorter period.
+func (d *ImpairmentDetector) isRepeatedFrame(samples []float64) bool {
+	joined := append(append([]float64(nil), d.history...), samples...)
+	base := len(d.history)
+
+	for _, ms := range []int{10, 20} {
+		frame := d.sampleRate * ms / 1000
+		if frame <= 0 || 2*frame > base {
+			continue
+		}
+		for at := base; at+frame <= len(joined); at += frame {
+			copied, source, before := joined[at:at+frame], joined[at-frame:at], joined[at-2*frame:at-frame]
+			if sameSamples(copied, source) && !sameSamples(source, before) &&
+				calculateRMS(copied) > zeroSampleLevel && !exactlyPeriodic(copied, frame/2) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameSamples reports whether a and b match sample for sample
+func sameSamples(a, b []float64) bool {
+	for i := range a {
+		if math.Abs(a[i]-b[i]) >= zeroSampleLevel {
+			return false
+		}
+	}
+	return true
+}
+
+// exactlyPeriodic reports whether segment repeats sample-exactly at some lag
+// up to maxLag
+func exactlyPeriodic(segment []float64, maxLag int) bool {
+	for lag := 1; lag <= maxLag && lag < len(segment); lag++ {
+		periodic := true
+		for i := lag; i < len(segment); i++ {
+			if math.Abs(segment[i]-segment[i-lag]) >= zeroSampleLevel {
+				periodic = false
+				break
+			}
+		}
+		if periodic {
+			return true
+		}
+	}
+	return false
+}
+
+// isComfortNoise checks for stationary flat noise between the silence and
+// speech levels. Comfort noise stands in for the background once a talk spurt
+// ends, so it is only looked for after a non-silent segment; below the
+// silence level it is silence either way, and a recording's own quiet floor
+// would be flagged in every pause.
+func (d *ImpairmentDetector) isComfortNoise(rms, flatness, silenceLevel, speechLevel float64) bool {
+	if !d.hadSound || len(d.levels) < comfortNoiseFrames || rms < silenceLevel || rms >= speechLevel {
+		return false
+	}
+	if flatness < comfortNoiseFlat {
+		return false
+	}
+
+	var mean float64
+	for _, l := range d.levels {
+		mean += l
+	}
+	mean /= float64(len(d.levels))
+
+	var variance float64
+	for _, l := range d.levels {
+		variance += (l - mean) * (l - mean)
+	}
+	return mean > 0 && math.Sqrt(variance/float64(len(d.levels)))/mean < comfortNoiseMaxCV
+}
@@ -0,0 +1,300 @@
+package detector
+
+import (
+	"math"
+	"math/cmplx"
+	"time"
+
+	"retape_ai/internal/audio"
+	"retape_ai/internal/config"
+)
+
+type MusicEvent struct {
+	Playing   bool // true = music started, false = music stopped
+	StartTime time.Duration
+	EndTime   time.Duration // set when Playing is false
+	Score     float64
+}
+
+type MusicFeatures struct {
+	Harmonicity    float64 // mean normalized autocorrelation peak over the window
+	LowEnergyRatio float64 // share of chunks well below the window's mean energy
+	FluxVariation  float64 // coefficient of variation of spectral flux
+	Rhythm         float64 // strongest periodicity of the energy envelope at beat rates
+}
+
+type musicFrame struct {
+	rms         float64
+	harmonicity float64
+	flux        float64
+	timestamp   time.Duration
+	end         time.Duration
+}
+
+// MusicDetector discriminates music beds and hold music from speech. Speech
+// keeps stopping (pauses, unvoiced consonants) and its spectrum jumps around
+// syllable by syllable; music is continuous, strongly harmonic, spectrally
+// steadier and often has a regular beat.
+type MusicDetector struct {
+	config     *config.Config
+	sampleRate int
+
+	frames       []musicFrame
+	prevSpectrum []float64
+	playing      bool
+	startTime    time.Duration
+	lastLoudEnd  time.Duration
+	quietChunks  int
+	offChunks    int
+	features     MusicFeatures
+	score        float64
+}
+
+func NewMusicDetector(cfg *config.Config, sampleRate int) *MusicDetector {
+	return &MusicDetector{
+		config:     cfg,
+		sampleRate: sampleRate,
+		frames:     make([]musicFrame, 0),
+	}
+}
+
+func (d *MusicDetector) Process(chunk audio.AudioChunk) *MusicEvent {
+	if !d.config.EnableMusicDetection || len(chunk.Samples) < 64 {
+		return nil
+	}
+
+	frame := musicFrame{
+		rms:         calculateRMS(chunk.Samples),
+		harmonicity: harmonicity(chunk.Samples, d.sampleRate),
+		timestamp:   chunk.Timestamp,
+		end:         chunk.Timestamp + chunk.Duration,
+	}
+	frame.flux = d.spectralFlux(chunk.Samples)
+
+	windowLen := int(d.config.MusicWindow / chunk.Duration)
+	d.frames = append(d.frames, frame)
+	if len(d.frames) > windowLen {
+		d.frames = d.frames[1:]
+	}
+
+	isLoud := frame.rms >= d.config.SilenceThreshold
+	if isLoud {
+		d.quietChunks = 0
+		if d.playing {
+			d.lastLoudEnd = frame.end
+		}
+	} else {
+		d.quietChunks++
+	}
+
+	if len(d.frames) < windowLen {
+		return nil
+	}
+
+	d.features = d.computeFeatures()
+	d.score = musicScore(d.features)
+	isMusic := d.score >= 0.75 // continuity and tonality are both required
+
+	if !d.playing {
+		if isMusic && isLoud {
+			d.playing = true
+			d.offChunks = 0
+			d.startTime = d.frames[0].timestamp
+			d.lastLoudEnd = frame.end
+			return &MusicEvent{Playing: true, StartTime: d.startTime, Score: d.score}
+		}
+		return nil
+	}
+
+	// Music stops either by going quiet or by the window no longer looking like music
+	if isMusic {
+		d.offChunks = 0
+	} else {
+		d.offChunks++
+	}
+	quietLimit := int(300 * time.Millisecond / chunk.Duration)
+	offLimit := int(500 * time.Millisecond / chunk.Duration)
+
+	if d.quietChunks >= quietLimit || d.offChunks >= offLimit {
+		d.playing = false
+		end := d.lastLoudEnd
+		if d.offChunks >= offLimit {
+			// The window lags the change, so the music ended around when the score dropped
+			end = frame.end - time.Duration(d.offChunks)*chunk.Duration
+		}
+		return &MusicEvent{Playing: false, StartTime: d.startTime, EndTime: end, Score: d.score}
+	}
+
+	return nil
+}
+
+func (d *MusicDetector) IsPlaying() bool {
+	return d.playing
+}
+
+// IsSounding reports whether music is playing and the latest chunk was not quiet
+func (d *MusicDetector) IsSounding() bool {
+	return d.playing && d.quietChunks == 0
+}
+
+func (d *MusicDetector) Features() MusicFeatures {
+	return d.features
+}
+
+func (d *MusicDetector) computeFeatures() MusicFeatures {
+	n := float64(len(d.frames))
+
+	var meanRMS, meanHarm, meanFlux float64
+	for _, f := range d.frames {
+		meanRMS += f.rms
+		meanHarm += f.harmonicity
+		meanFlux += f.flux
+	}
+	meanRMS /= n
+	meanHarm /= n
+	meanFlux /= n
+
+	var lowEnergy, fluxVar float64
+	for _, f := range d.frames {
+		if f.rms < 0.5*meanRMS {
+			lowEnergy++
+		}
+		fluxVar += (f.flux - meanFlux) * (f.flux - meanFlux)
+	}
+
+	features := MusicFeatures{
+		Harmonicity:    meanHarm,
+		LowEnergyRatio: lowEnergy / n,
+	}
+	if meanFlux > 0 {
+		features.FluxVariation = math.Sqrt(fluxVar/n) / meanFlux
+	}
+	features.Rhythm = d.envelopeRhythm(meanRMS)
+
+	return features
+}
+
+// envelopeRhythm finds the strongest autocorrelation of the energy envelope at
+// beat-rate lags (250ms - 1s, i.e. 60-240 BPM)
+func (d *MusicDetector) envelopeRhythm(mean float64) float64 {
+	n := len(d.frames)
+	if n < 4 || mean == 0 {
+		return 0
+	}
+
+	chunkDur := d.frames[0].end - d.frames[0].timestamp
+	if chunkDur <= 0 {
+		return 0
+	}
+	minLag := int(250 * time.Millisecond / chunkDur)
+	maxLag := int(time.Second / chunkDur)
+	if maxLag > n/2 {
+		maxLag = n / 2
+	}
+
+	var energy float64
+	for _, f := range d.frames {
+		energy += (f.rms - mean) * (f.rms - mean)
+	}
+	if energy == 0 {
+		return 0
+	}
+
+	var best float64
+	for lag := minLag; lag <= maxLag; lag++ {
+		var sum float64
+		for i := 0; i+lag < n; i++ {
+			sum += (d.frames[i].rms - mean) * (d.frames[i+lag].rms - mean)
+		}
+		r := sum / energy * float64(n) / float64(n-lag)
+		if r > best {
+			best = r
+		}
+	}
+	return best
+}
+
+func (d *MusicDetector) spectralFlux(samples []float64) float64 {
+	n := nextPowerOf2(len(samples))
+	padded := make([]complex128, n)
+	for i, s := range samples {
+		window := 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(len(samples)-1)))
+		padded[i] = complex(s*window, 0)
+	}
+	spectrum := computeFFT(padded)
+
+	// Normalize so flux measures change in spectral shape, not loudness
+	mags := make([]float64, n/2)
+	var total float64
+	for i := range mags {
+		mags[i] = cmplx.Abs(spectrum[i])
+		total += mags[i]
+	}
+	if total > 0 {
+		for i := range mags {
+			mags[i] /= total
+		}
+	}
+
+	var flux float64
+	if len(d.prevSpectrum) == len(mags) {
+		for i := range mags {
+			if diff := mags[i] - d.prevSpectrum[i]; diff > 0 {
+				flux += diff
+			}
+		}
+	}
+	d.prevSpectrum = mags
+	return flux
+}
+
+// musicScore combines the window features into a 0-1 music likelihood. Music
+// needs both continuous sound (speech always has low-energy gaps) and tonal
+// content (rules out hiss); beat and spectral steadiness add confidence.
+func musicScore(f MusicFeatures) float64 {
+	score := 0.0
+	if f.LowEnergyRatio < 0.1 {
+		score += 0.4
+	}
+	if f.Harmonicity > 0.6 {
+		score += 0.35
+	}
+	if f.Rhythm > 0.4 {
+		score += 0.125
+	}
+	if f.FluxVariation < 1.0 {
+		score += 0.125
+	}
+	return score
+}
+
+// harmonicity returns the normalized autocorrelation peak for pitch lags from
+// 1000 Hz down to 50 Hz, or as low as the chunk allows (1 = perfectly periodic)
+func harmonicity(samples []float64, sampleRate int) float64 {
+	minLag := sampleRate / 1000
+	maxLag := sampleRate / 50
+	if maxLag >= len(samples)/2 {
+		maxLag = len(samples)/2 - 1
+	}
+	if minLag < 1 || maxLag <= minLag {
+		return 0
+	}
+
+	var best float64
+	for lag := minLag; lag <= maxLag; lag++ {
+		var sum, e1, e2 float64
+		for i := 0; i+lag < len(samples); i++ {
+			sum += samples[i] * samples[i+lag]
+			e1 += samples[i] * samples[i]
+			e2 += samples[i+lag] * samples[i+lag]
+		}
+		if e1 == 0 || e2 == 0 {
+			continue
+		}
+		r := sum / math.Sqrt(e1*e2)
+		if r > best {
+			best = r
+		}
+	}
+	return best
+}
@@ -0,0 +1,327 @@
+package detector
+
+import (
+	"fmt"
+	"time"
+
+	"retape_ai/internal/audio"
+	"retape_ai/internal/config"
+)
+
+// Network tone types reported in NetworkToneEvent.Type
+const (
+	ToneSITIntercept   = "sit-intercept"
+	ToneSITVacantCode  = "sit-vacant-code"
+	ToneSITNoCircuit   = "sit-no-circuit"
+	ToneSITReorder     = "sit-reorder"
+	ToneSITIneffective = "sit-ineffective-other"
+	ToneSITUnknown     = "sit-unknown"
+	ToneBusy           = "busy"
+	ToneReorder        = "reorder"
+	ToneRingback       = "ringback"
+)
+
+type NetworkToneEvent struct {
+	Type      string
+	StartTime time.Duration
+	EndTime   time.Duration
+	Details   string
+}
+
+// Per-chunk tone classes
+const (
+	toneNone = iota
+	toneSITLow
+	toneSITMid
+	toneSITHigh
+	toneBusyPair     // 480 + 620 Hz (busy and reorder share frequencies)
+	toneRingbackPair // 440 + 480 Hz
+)
+
+// SIT segment durations: short ~276ms, long ~380ms
+const (
+	sitMinSegment   = 200 * time.Millisecond
+	sitMaxSegment   = 450 * time.Millisecond
+	sitLongSegment  = 327 * time.Millisecond
+	sitMaxGap       = 60 * time.Millisecond
+	ringbackMinOn   = 1500 * time.Millisecond
+	cadenceHoldover = 700 * time.Millisecond
+)
+
+type toneSegment struct {
+	class     int
+	high      bool // SIT only: upper frequency of the pair (985.2 / 1428.5 Hz)
+	startTime time.Duration
+	endTime   time.Duration
+}
+
+func (s toneSegment) duration() time.Duration {
+	return s.endTime - s.startTime
+}
+
+// NetworkToneClassifier recognizes carrier call-progress tones: the three-tone
+// Special Information Tone sequence, busy, reorder and ringback. These mean the
+// call never reached a mailbox, so nothing should be dropped.
+type NetworkToneClassifier struct {
+	config     *config.Config
+	sampleRate int
+
+	current   toneSegment
+	history   []toneSegment
+	lastChunk time.Duration
+	detected  *NetworkToneEvent
+
+	// Ringing since the first full ring, until speech answers it
+	ringing   bool
+	ringStart time.Duration
+	ringEnd   time.Duration
+}
+
+func NewNetworkToneClassifier(cfg *config.Config, sampleRate int) *NetworkToneClassifier {
+	return &NetworkToneClassifier{
+		config:     cfg,
+		sampleRate: sampleRate,
+		history:    make([]toneSegment, 0),
+	}
+}
+
+func (c *NetworkToneClassifier) Process(chunk audio.AudioChunk) *NetworkToneEvent {
+	if !c.config.EnableNetworkTones || c.detected != nil || len(chunk.Samples) < 64 {
+		return nil
+	}
+
+	class, high := c.classifyChunk(chunk.Samples)
+	c.lastChunk = chunk.Timestamp + chunk.Duration
+
+	if class == c.current.class && high == c.current.high {
+		c.current.endTime = c.lastChunk
+
+		// A full ring starts the no-answer clock; ringback is identified once
+		// ringing outlasts it
+		if class == toneRingbackPair && c.current.duration() >= ringbackMinOn {
+			if !c.ringing {
+				c.ringing = true
+				c.ringStart = c.current.startTime
+			}
+			c.ringEnd = c.current.endTime
+			if c.current.endTime-c.ringStart >= c.config.RingbackNoAnswer {
+				c.detected = &NetworkToneEvent{
+					Type:      ToneRingback,
+					StartTime: c.ringStart,
+					EndTime:   c.current.endTime,
+					Details:   fmt.Sprintf("440+480 Hz, ringing for %v", c.current.endTime-c.ringStart),
+				}
+				return c.detected
+			}
+		}
+		return nil
+	}
+
+	// Class changed - close the running segment
+	if c.current.class != toneNone {
+		c.history = append(c.history, c.current)
+		if len(c.history) > 8 {
+			c.history = c.history[1:]
+		}
+		c.detected = c.matchSequence()
+	}
+
+	c.current = toneSegment{
+		class:     class,
+		high:      high,
+		startTime: chunk.Timestamp,
+		endTime:   c.lastChunk,
+	}
+
+	return c.detected
+}
+
+// Pending reports whether a partial call-progress tone pattern is in progress,
+// so callers can hold off treating the tones as voicemail beeps
+func (c *NetworkToneClassifier) Pending() bool {
+	if !c.config.EnableNetworkTones || c.detected != nil {
+		return false
+	}
+
+	switch c.current.class {
+	case toneSITLow, toneSITMid, toneSITHigh:
+		return c.current.duration() <= sitMaxSegment
+	case toneBusyPair, toneRingbackPair:
+		return true
+	}
+
+	if len(c.history) == 0 {
+		return false
+	}
+	last := c.history[len(c.history)-1]
+	gap := c.lastChunk - last.endTime
+
+	switch last.class {
+	case toneSITLow, toneSITMid:
+		return gap <= sitMaxGap && last.duration() <= sitMaxSegment
+	case toneBusyPair:
+		return gap <= cadenceHoldover
+	}
+	return false
+}
+
+// Reset forgets the tones heard so far. Speech means the call was answered,
+// so earlier rings no longer count toward no-answer.
+func (c *NetworkToneClassifier) Reset() {
+	c.current = toneSegment{}
+	c.history = c.history[:0]
+	c.ringing = false
+}
+
+// RingingUntil returns when the latest full ring ended, or 0 if there has been
+// no ring since the call was answered
+func (c *NetworkToneClassifier) RingingUntil() time.Duration {
+	if !c.ringing {
+		return 0
+	}
+	return c.ringEnd
+}
+
+func (c *NetworkToneClassifier) Detected() *NetworkToneEvent {
+	return c.detected
+}
+
+func (c *NetworkToneClassifier) matchSequence() *NetworkToneEvent {
+	n := len(c.history)
+
+	// SIT: low, mid, high tones back to back
+	if n >= 3 {
+		first, second, third := c.history[n-3], c.history[n-2], c.history[n-1]
+		if first.class == toneSITLow && second.class == toneSITMid && third.class == toneSITHigh &&
+			isSITSegment(first) && isSITSegment(second) && isSITSegment(third) &&
+			second.startTime-first.endTime <= sitMaxGap && third.startTime-second.endTime <= sitMaxGap {
+			return &NetworkToneEvent{
+				Type:      classifySIT(first, second),
+				StartTime: first.startTime,
+				EndTime:   third.endTime,
+				Details: fmt.Sprintf("SIT %s/%s/%s",
+					sitSegmentLabel(first), sitSegmentLabel(second), sitSegmentLabel(third)),
+			}
+		}
+	}
+
+	// Busy and reorder: two on-periods of 480+620 Hz with matching cadence
+	if n >= 2 {
+		var onPeriods []toneSegment
+		for _, seg := range c.history {
+			if seg.class == toneBusyPair {
+				onPeriods = append(onPeriods, seg)
+			}
+		}
+		if len(onPeriods) >= 2 {
+			a, b := onPeriods[len(onPeriods)-2], onPeriods[len(onPeriods)-1]
+			off := b.startTime - a.endTime
+			toneType := ""
+			switch {
+			case inRange(a.duration(), 400, 650) && inRange(b.duration(), 400, 650) && inRange(off, 350, 650):
+				toneType = ToneBusy
+			case inRange(a.duration(), 180, 350) && inRange(b.duration(), 180, 350) && inRange(off, 150, 350):
+				toneType = ToneReorder
+			}
+			if toneType != "" {
+				return &NetworkToneEvent{
+					Type:      toneType,
+					StartTime: a.startTime,
+					EndTime:   b.endTime,
+					Details:   fmt.Sprintf("480+620 Hz, on=%v off=%v", b.duration(), off),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *NetworkToneClassifier) classifyChunk(samples []float64) (int, bool) {
+	return classifyTone(samples, c.sampleRate, c.config.NetworkToneMinAmplitude)
+}
+
+// classifyTone returns the network tone class of one chunk, and for SIT
+// whether it is the upper frequency of the pair
+func classifyTone(samples []float64, sampleRate int, minAmp float64) (int, bool) {
+	rms := calculateRMS(samples)
+	power := rms * rms
+	if power == 0 {
+		return toneNone, false
+	}
+
+	amp := func(freq float64) float64 {
+		return goertzelAmplitude(samples, freq, sampleRate)
+	}
+
+	// Dual tones first - both components present and carrying most energy
+	a440, a480, a620 := amp(440), amp(480), amp(620)
+	if a480 >= minAmp && a620 >= minAmp && (a480*a480+a620*a620)/2 >= 0.6*power {
+		return toneBusyPair, false
+	}
+	if a440 >= minAmp && a480 >= minAmp && (a440*a440+a480*a480)/2 >= 0.6*power {
+		return toneRingbackPair, false
+	}
+
+	// SIT single tones, picking the stronger of each frequency pair
+	candidates := []struct {
+		class int
+		low   float64
+		high  float64
+	}{
+		{toneSITLow, 913.8, 985.2},
+		{toneSITMid, 1370.6, 1428.5},
+		{toneSITHigh, 1776.7, 1776.7},
+	}
+	for _, cand := range candidates {
+		lowAmp, highAmp := amp(cand.low), amp(cand.high)
+		best, isHigh := lowAmp, false
+		if highAmp > lowAmp && cand.high != cand.low {
+			best, isHigh = highAmp, true
+		}
+		if best >= minAmp && best*best/2 >= 0.6*power {
+			return cand.class, isHigh
+		}
+	}
+
+	return toneNone, false
+}
+
+func isSITSegment(seg toneSegment) bool {
+	d := seg.duration()
+	return d >= sitMinSegment && d <= sitMaxSegment
+}
+
+func sitSegmentLabel(seg toneSegment) string {
+	if seg.duration() >= sitLongSegment {
+		return "long"
+	}
+	return "short"
+}
+
+// classifySIT maps the first two SIT segments (frequency + duration) to the
+// Telcordia SIT code. The third segment is always 1776.7 Hz long.
+func classifySIT(first, second toneSegment) string {
+	firstLong := first.duration() >= sitLongSegment
+	secondLong := second.duration() >= sitLongSegment
+
+	switch {
+	case !first.high && !firstLong && !second.high && !secondLong:
+		return ToneSITIntercept
+	case first.high && firstLong && !second.high && !secondLong:
+		return ToneSITVacantCode
+	case first.high && !firstLong && second.high && secondLong,
+		!first.high && firstLong && !second.high && secondLong:
+		return ToneSITNoCircuit
+	case !first.high && firstLong && second.high && !secondLong,
+		first.high && !firstLong && !second.high && secondLong:
+		return ToneSITReorder
+	case first.high && !firstLong && second.high && !secondLong:
+		return ToneSITIneffective
+	}
+	return ToneSITUnknown
+}
+
+func inRange(d time.Duration, minMs, maxMs int) bool {
+	return d >= time.Duration(minMs)*time.Millisecond && d <= time.Duration(maxMs)*time.Millisecond
+}
@@ -0,0 +1,81 @@
+package detector
+
+import (
+	"testing"
+	"time"
+
+	"retape_ai/internal/config"
+)
+
+// classify runs s through a fresh classifier and returns it with the tone it
+// detected, "" if none
+func classify(s signal) (*NetworkToneClassifier, string) {
+	c := NewNetworkToneClassifier(config.DefaultConfig(), testSampleRate)
+	for _, chunk := range s.chunks() {
+		if event := c.Process(chunk); event != nil {
+			return c, event.Type
+		}
+	}
+	return c, ""
+}
+
+func TestNetworkToneCadence(t *testing.T) {
+	const (
+		low, lowHigh = 913.8, 985.2
+		mid, midHigh = 1370.6, 1428.5
+		high         = 1776.7
+		short, long  = 276, 380
+	)
+	sit := func(f1 float64, d1 int, f2 float64, d2 int) signal {
+		return signal(nil).silence(ms(200)).
+			tone(ms(d1), 0.1, f1).tone(ms(d2), 0.1, f2).tone(ms(long), 0.1, high).
+			silence(ms(200))
+	}
+
+	tests := []struct {
+		name string
+		s    signal
+		want string
+	}{
+		{"SIT intercept", sit(low, short, mid, short), ToneSITIntercept},
+		{"SIT vacant code", sit(lowHigh, long, mid, short), ToneSITVacantCode},
+		{"SIT no circuit", sit(low, long, mid, long), ToneSITNoCircuit},
+		{"SIT reorder", sit(low, long, midHigh, short), ToneSITReorder},
+		{"SIT ineffective", sit(lowHigh, short, midHigh, short), ToneSITIneffective},
+		{"SIT out of order", sit(mid, short, low, short).silence(ms(200)), ""},
+		{"busy", signal(nil).cadence(3*time.Second, ms(500), ms(500), 0.1, 480, 620), ToneBusy},
+		{"reorder", signal(nil).cadence(2*time.Second, ms(250), ms(250), 0.1, 480, 620), ToneReorder},
+		{"busy pair at the wrong cadence", signal(nil).cadence(4*time.Second, ms(1000), ms(1000), 0.1, 480, 620), ""},
+		{"a few rings", signal(nil).cadence(12*time.Second, 2*time.Second, 4*time.Second, 0.1, 440, 480), ""},
+		{"unanswered ringing", signal(nil).cadence(36*time.Second, 2*time.Second, 4*time.Second, 0.1, 440, 480), ToneRingback},
+		{"beep", signal(nil).silence(ms(200)).tone(ms(500), 0.2, 1000).silence(ms(500)), ""},
+		{"speech", signal(nil).speech(2 * time.Second), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := classify(tt.s); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNetworkToneRinging(t *testing.T) {
+	rings := signal(nil).cadence(12*time.Second, 2*time.Second, 4*time.Second, 0.1, 440, 480)
+	c, _ := classify(rings)
+
+	// Two rings, at 0-2s and 6-8s
+	if got := c.RingingUntil(); got < 7900*time.Millisecond || got > 8100*time.Millisecond {
+		t.Fatalf("expected ringing until ~8s, got %v", got)
+	}
+
+	// An answer resets the no-answer clock
+	c.Reset()
+	if got := c.RingingUntil(); got != 0 {
+		t.Fatalf("expected no ringing after Reset, got %v", got)
+	}
+	if c.Pending() {
+		t.Fatal("expected nothing pending after Reset")
+	}
+}
@@ -0,0 +1,185 @@
+package detector
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	maxPeaksPerChunk  = 5
+	peakProminence    = 3.0   // peak vs the median of its neighbourhood
+	peakNeighbourhood = 5     // bins either side used for the local median
+	trackMaxMisses    = 1     // chunks a track may lose its peak and still continue
+	trackMaxDeviation = 0.005 // median relative frequency deviation of a steady tone
+	trackMinDominant  = 2     // chunks where the tone must also be the strongest peak
+	maxBackdate       = 500 * time.Millisecond
+	maxBridgeChunks   = 10 // consecutive chunks a beep may be hidden under speech
+)
+
+type spectralPeak struct {
+	freq       float64 // parabolic-interpolated
+	amp        float64
+	prominence float64
+}
+
+// peakTrack follows one narrowband peak across chunks. The tone doesn't have
+// to dominate the chunk, so a beep keeps its track while speech overlaps it.
+type peakTrack struct {
+	run      toneRun
+	end      time.Duration
+	misses   int
+	dominant int
+	matched  bool
+}
+
+func (t *peakTrack) freq() float64 {
+	return t.run.lastFreq()
+}
+
+func (t *peakTrack) duration() time.Duration {
+	return t.end - t.run.start
+}
+
+type peakTracker struct {
+	tracks []*peakTrack
+}
+
+// update matches this chunk's peaks to the running tracks and returns the
+// tracks that ended. dominant is the chunk's beep-like dominant frequency (0
+// if the chunk isn't beep-like) and pitch its pitch estimate, if any.
+func (p *peakTracker) update(peaks []spectralPeak, start, end time.Duration, rms, preceding, dominant, resolution float64, pitch *PitchEstimate) []*peakTrack {
+	for _, t := range p.tracks {
+		t.matched = false
+	}
+
+	for _, peak := range peaks {
+		track := p.nearest(peak.freq, resolution, true)
+		if track == nil {
+			track = &peakTrack{run: toneRun{start: start, preceding: preceding}}
+			p.tracks = append(p.tracks, track)
+		}
+		track.matched = true
+		track.misses = 0
+		track.end = end
+		track.run.add(peak.freq, peak.prominence, rms)
+		track.run.peakFreqs = append(track.run.peakFreqs, peak.freq)
+		track.run.maxAmp = math.Max(track.run.maxAmp, peak.amp)
+
+		if dominant > 0 && math.Abs(dominant-peak.freq) <= resolution {
+			track.dominant++
+			if pitch != nil {
+				track.run.addPitch(*pitch)
+			}
+		}
+	}
+
+	var ended []*peakTrack
+	active := p.tracks[:0]
+	for _, t := range p.tracks {
+		if !t.matched {
+			t.misses++
+		}
+		if t.misses > trackMaxMisses {
+			ended = append(ended, t)
+			continue
+		}
+		active = append(active, t)
+	}
+	p.tracks = active
+	return ended
+}
+
+// trackAt returns the running track closest to freq, if any
+func (p *peakTracker) trackAt(freq, resolution float64) *peakTrack {
+	return p.nearest(freq, resolution, false)
+}
+
+func (p *peakTracker) nearest(freq, resolution float64, skipMatched bool) *peakTrack {
+	var best *peakTrack
+	bestDiff := math.Max(resolution, freq*0.02)
+	for _, t := range p.tracks {
+		if skipMatched && t.matched {
+			continue
+		}
+		if diff := math.Abs(t.freq() - freq); diff <= bestDiff {
+			best, bestDiff = t, diff
+		}
+	}
+	return best
+}
+
+func (p *peakTracker) reset() {
+	p.tracks = p.tracks[:0]
+}
+
+// findPeaks returns the strongest narrowband peaks between minBin and maxBin.
+// Prominence is measured against the local median rather than the whole band,
+// so a tone still stands out with speech energy elsewhere in the spectrum.
+func findPeaks(mags []float64, minBin, maxBin, fftSize int, resolution, minAmp float64) []spectralPeak {
+	var peaks []spectralPeak
+	neighbours := make([]float64, 0, 2*peakNeighbourhood)
+
+	for i := minBin; i <= maxBin; i++ {
+		if i < 1 || i+1 >= len(mags) || mags[i] < mags[i-1] || mags[i] < mags[i+1] {
+			continue
+		}
+		amp := mags[i] / float64(fftSize) * 2
+		if amp < minAmp {
+			continue
+		}
+
+		neighbours = neighbours[:0]
+		for j := i - peakNeighbourhood; j <= i+peakNeighbourhood; j++ {
+			if j < 1 || j >= len(mags) || (j >= i-1 && j <= i+1) {
+				continue
+			}
+			neighbours = append(neighbours, mags[j])
+		}
+		if len(neighbours) == 0 {
+			continue
+		}
+		local := medianOf(neighbours)
+		if local > 0 && mags[i] < local*peakProminence {
+			continue
+		}
+		prominence := peakProminence * 2
+		if local > 0 {
+			prominence = mags[i] / local
+		}
+
+		peaks = append(peaks, spectralPeak{
+			freq:       (float64(i) + parabolicOffset(mags[i-1], mags[i], mags[i+1])) * resolution,
+			amp:        amp,
+			prominence: prominence,
+		})
+	}
+
+	sort.Slice(peaks, func(a, b int) bool { return peaks[a].amp > peaks[b].amp })
+	if len(peaks) > maxPeaksPerChunk {
+		peaks = peaks[:maxPeaksPerChunk]
+	}
+	return peaks
+}
+
+// parabolicOffset locates a spectral peak between bins from its neighbours' log magnitudes
+func parabolicOffset(a, b, c float64) float64 {
+	if a <= 0 || b <= 0 || c <= 0 {
+		return 0
+	}
+	la, lb, lc := math.Log(a), math.Log(b), math.Log(c)
+	denom := la - 2*lb + lc
+	if denom == 0 {
+		return 0
+	}
+	return 0.5 * (la - lc) / denom
+}
+
+// qualifiesAsBeep checks an ended track for the overlap path: long and steady
+// enough, loud enough, clean for at least a couple of chunks, and not a voice
+func (t *peakTrack) qualifiesAsBeep(minHits int, minAmp float64) bool {
+	return len(t.run.freqs) >= minHits &&
+		t.dominant >= trackMinDominant &&
+		t.run.maxAmp >= minAmp &&
+		relativeDeviation(t.run.freqs) <= trackMaxDeviation
+}
@@ -7,9 +7,20 @@ import (
 	"retape_ai/internal/config"
 )
 
+// Phrase categories reported in PhraseEvent.Category
+const (
+	PhraseBeepCue      = "beep_cue"      // "after the beep", "at the tone" - a beep is coming
+	PhraseLeaveMessage = "leave_message" // "leave a message", "record your message"
+	PhraseCallback     = "callback"      // "leave your name/number" - usually the last line
+	PhraseOther        = "other"
+	PhrasePersonal     = "personal"    // the callee's own name - personal greeting content, not an end phrase
+	PhraseUnavailable  = "unavailable" // "the mailbox is full" - no message can be left
+)
+
 type PhraseEvent struct {
 	Timestamp time.Duration
 	Phrase    string
+	Category  string
 	FullText  string
 }
 
@@ -17,6 +28,9 @@ type PhraseDetector struct {
 	config   *config.Config
 	patterns []*regexp.Regexp
 	detected *PhraseEvent
+
+	namePattern  *regexp.Regexp
+	nameDetected *PhraseEvent
 }
 
 func NewPhraseDetector(cfg *config.Config) *PhraseDetector {
@@ -39,6 +53,7 @@ func (d *PhraseDetector) Process(text string, timestamp time.Duration) *PhraseEv
 			event := &PhraseEvent{
 				Timestamp: timestamp,
 				Phrase:    d.config.EndPhrases[i],
+				Category:  phraseCategory(d.config.EndPhrases[i]),
 				FullText:  text,
 			}
 			d.detected = event
@@ -52,3 +67,80 @@ func (d *PhraseDetector) Process(text string, timestamp time.Duration) *PhraseEv
 func (d *PhraseDetector) GetDetected() *PhraseEvent {
 	return d.detected
 }
+
+// ExpectName makes the detector recognize the callee's name as personal
+// greeting content: "you've reached Mike Rodriguez", "this is Mike",
+// "Mike's voicemail", "Mike can't come to the phone". The full name or the
+// first name alone will do, but only in those framings, since plenty of
+// first names are also ordinary words.
+func (d *PhraseDetector) ExpectName(name string) {
+	full := normalizePrompt(name)
+	if full == "" {
+		return
+	}
+	names := regexp.QuoteMeta(full)
+	if first, _, found := strings.Cut(full, " "); found {
+		names += "|" + regexp.QuoteMeta(first)
+	}
+
+	d.namePattern = regexp.MustCompile(`\b(?:` +
+		`(?:you've reached|you have reached|you reached|this is|it's|i'm|hi|hey|hello) (?:` + names + `)` +
+		`|(?:` + names + `)(?:'s (?:voicemail|phone|mailbox|line)| is not| isn't| can't| cannot| is unavailable)` +
+		`)\b`)
+}
+
+// ProcessName looks for the expected name in a transcript. Nothing matches
+// until ExpectName has been called.
+func (d *PhraseDetector) ProcessName(text string, timestamp time.Duration) *PhraseEvent {
+	if d.namePattern == nil {
+		return nil
+	}
+	text = normalizePrompt(strings.ReplaceAll(text, "’", "'"))
+	if match := d.namePattern.FindString(text); match != "" {
+		event := &PhraseEvent{
+			Timestamp: timestamp,
+			Phrase:    match,
+			Category:  PhrasePersonal,
+			FullText:  text,
+		}
+		d.nameDetected = event
+		return event
+	}
+	return nil
+}
+
+// ProcessUnavailable looks for a prompt saying the mailbox cannot take a
+// message
+func (d *PhraseDetector) ProcessUnavailable(text string, timestamp time.Duration) *PhraseEvent {
+	normalized := normalizePrompt(text)
+	for _, phrase := range d.config.UnavailablePhrases {
+		if strings.Contains(normalized, normalizePrompt(phrase)) {
+			return &PhraseEvent{
+				Timestamp: timestamp,
+				Phrase:    phrase,
+				Category:  PhraseUnavailable,
+				FullText:  text,
+			}
+		}
+	}
+	return nil
+}
+
+// NameDetected returns the latest mention of the expected name, or nil
+func (d *PhraseDetector) NameDetected() *PhraseEvent {
+	return d.nameDetected
+}
+
+// phraseCategory classifies an end phrase by what it says about the rest of the greeting
+func phraseCategory(phrase string) string {
+	phrase = strings.ToLower(phrase)
+	switch {
+	case strings.Contains(phrase, "beep") || strings.Contains(phrase, "tone"):
+		return PhraseBeepCue
+	case strings.Contains(phrase, "name") || strings.Contains(phrase, "number"):
+		return PhraseCallback
+	case strings.Contains(phrase, "message") || strings.Contains(phrase, "leave"):
+		return PhraseLeaveMessage
+	}
+	return PhraseOther
+}
@@ -0,0 +1,148 @@
+package detector
+
+import (
+	"math"
+)
+
+const (
+	pitchMinFreq  = 70.0
+	pitchMaxFreq  = 500.0
+	yinThreshold  = 0.15
+	harmonicMaxHz = 3400.0
+)
+
+// PitchEstimate is the YIN pitch of a frame plus how its energy spreads over
+// the harmonics of that pitch
+type PitchEstimate struct {
+	Frequency float64 // f0 in Hz, 0 when the frame is aperiodic
+	Clarity   float64 // 1 - YIN aperiodicity, 1 = perfectly periodic
+
+	// HarmonicRichness is the share of harmonic power outside the harmonic
+	// nearest the dominant peak. A beep puts everything in one partial (~0);
+	// a voiced vowel spreads it across many.
+	HarmonicRichness float64
+
+	// Aligned reports whether the dominant peak sits on a harmonic of this
+	// pitch; if not, the pitch belongs to other audio overlapping the peak
+	Aligned bool
+}
+
+// PitchTracker runs YIN over the current chunk joined with the previous one,
+// so low voices still fit two periods in the analysis frame
+type PitchTracker struct {
+	sampleRate int
+	prev       []float64
+}
+
+func NewPitchTracker(sampleRate int) *PitchTracker {
+	return &PitchTracker{sampleRate: sampleRate}
+}
+
+// Observe records a chunk that isn't being analyzed, keeping the frame history current
+func (p *PitchTracker) Observe(samples []float64) {
+	p.prev = append(p.prev[:0], samples...)
+}
+
+// Estimate analyzes one chunk. dominant is the frequency of the spectral peak
+// the caller is interested in, used to split harmonic power.
+func (p *PitchTracker) Estimate(samples []float64, dominant float64) PitchEstimate {
+	frame := append(append([]float64(nil), p.prev...), samples...)
+	p.prev = append(p.prev[:0], samples...)
+
+	tau, clarity := yin(frame, p.sampleRate)
+	if tau == 0 {
+		return PitchEstimate{Clarity: clarity}
+	}
+
+	f0 := float64(p.sampleRate) / tau
+	k := math.Round(dominant / f0)
+	return PitchEstimate{
+		Frequency:        f0,
+		Clarity:          clarity,
+		HarmonicRichness: harmonicRichness(frame, f0, dominant, p.sampleRate),
+		Aligned:          k >= 1 && math.Abs(dominant/f0-k) <= 0.1,
+	}
+}
+
+// yin returns the fundamental period in samples (0 if none clears the
+// threshold) and the frame's periodicity, following de Cheveigné & Kawahara
+func yin(frame []float64, sampleRate int) (float64, float64) {
+	minLag := int(float64(sampleRate) / pitchMaxFreq)
+	maxLag := int(float64(sampleRate) / pitchMinFreq)
+	window := len(frame) - maxLag
+	if minLag < 2 || window < maxLag/2 {
+		return 0, 0
+	}
+
+	// Difference function and its cumulative mean normalization
+	cmnd := make([]float64, maxLag+2)
+	cmnd[0] = 1
+	var running float64
+	for tau := 1; tau <= maxLag+1 && tau+window <= len(frame); tau++ {
+		var d float64
+		for j := 0; j < window; j++ {
+			diff := frame[j] - frame[j+tau]
+			d += diff * diff
+		}
+		running += d
+		if running == 0 {
+			cmnd[tau] = 1
+		} else {
+			cmnd[tau] = d * float64(tau) / running
+		}
+	}
+
+	best, bestTau := math.Inf(1), 0
+	for tau := minLag; tau <= maxLag; tau++ {
+		if cmnd[tau] < yinThreshold {
+			// Walk down to the bottom of this dip
+			for tau+1 <= maxLag && cmnd[tau+1] < cmnd[tau] {
+				tau++
+			}
+			return parabolicPeak(cmnd, tau), 1 - cmnd[tau]
+		}
+		if cmnd[tau] < best {
+			best, bestTau = cmnd[tau], tau
+		}
+	}
+
+	if bestTau == 0 {
+		return 0, 0
+	}
+	return 0, math.Max(0, 1-best)
+}
+
+// parabolicPeak refines a minimum position from its two neighbours
+func parabolicPeak(values []float64, i int) float64 {
+	if i < 1 || i+1 >= len(values) {
+		return float64(i)
+	}
+	a, b, c := values[i-1], values[i], values[i+1]
+	denom := a - 2*b + c
+	if denom == 0 {
+		return float64(i)
+	}
+	return float64(i) + 0.5*(a-c)/denom
+}
+
+func harmonicRichness(frame []float64, f0, dominant float64, sampleRate int) float64 {
+	windowed := make([]float64, len(frame))
+	for i, s := range frame {
+		windowed[i] = s * 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(len(frame)-1)))
+	}
+
+	peakHarmonic := int(math.Round(dominant / f0))
+	var total, others float64
+	for k := 1; float64(k)*f0 <= harmonicMaxHz && float64(k)*f0 < float64(sampleRate)/2; k++ {
+		amp := goertzelAmplitude(windowed, float64(k)*f0, sampleRate)
+		power := amp * amp
+		total += power
+		if k != peakHarmonic {
+			others += power
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return others / total
+}
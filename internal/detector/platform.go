@@ -0,0 +1,127 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"retape_ai/internal/config"
+)
+
+type PlatformEvent struct {
+	Platform    string
+	Evidence    string // "prompt" or "context"
+	Details     string
+	Timestamp   time.Duration
+	Timing      config.TimingOverride
+	BeepMatched bool // the record beep matched the platform's beep fingerprint
+}
+
+// PlatformDetector identifies the carrier voicemail platform from the
+// configured fingerprint library. Only a canned system prompt (or the caller's
+// expectation) identifies a platform: generic beeps are shared by too many
+// systems, and by the time a beep is confirmed the drop has already been
+// decided, so a beep fingerprint only corroborates.
+type PlatformDetector struct {
+	config     *config.Config
+	identified *PlatformEvent
+}
+
+func NewPlatformDetector(cfg *config.Config) *PlatformDetector {
+	return &PlatformDetector{config: cfg}
+}
+
+// ProcessTranscript matches transcript text against the platforms' prompts
+func (d *PlatformDetector) ProcessTranscript(text string, timestamp time.Duration) *PlatformEvent {
+	if !d.config.EnablePlatformDetection || (d.identified != nil && d.identified.Evidence == "prompt") {
+		return nil
+	}
+
+	text = normalizePrompt(text)
+	for _, fp := range d.config.Platforms {
+		for _, prompt := range fp.Prompts {
+			if strings.Contains(text, normalizePrompt(prompt)) {
+				d.identified = &PlatformEvent{
+					Platform:  fp.Name,
+					Evidence:  "prompt",
+					Details:   fmt.Sprintf("prompt '%s'", prompt),
+					Timestamp: timestamp,
+					Timing:    fp.Timing,
+				}
+				return d.identified
+			}
+		}
+	}
+	return nil
+}
+
+// Expect takes the platform the caller expects as a working identification
+// until a prompt or beep says otherwise. It returns nil for unknown names.
+func (d *PlatformDetector) Expect(name string) *PlatformEvent {
+	if !d.config.EnablePlatformDetection {
+		return nil
+	}
+	for _, fp := range d.config.Platforms {
+		if fp.Name == name {
+			d.identified = &PlatformEvent{
+				Platform: fp.Name,
+				Evidence: "context",
+				Details:  "expected by the caller",
+				Timing:   fp.Timing,
+			}
+			return d.identified
+		}
+	}
+	return nil
+}
+
+// ProcessBeep checks a verified beep against the identified platform's beep
+// fingerprint and reports whether it matched. It never identifies a platform
+// on its own.
+func (d *PlatformDetector) ProcessBeep(beep *BeepEvent) bool {
+	if !d.config.EnablePlatformDetection || beep == nil || d.identified == nil {
+		return false
+	}
+
+	duration := beep.EndTime - beep.StartTime
+	for _, fp := range d.config.Platforms {
+		if fp.Name != d.identified.Platform || fp.BeepFreq == 0 {
+			continue
+		}
+		if math.Abs(beep.Frequency-fp.BeepFreq) > fp.BeepFreqTol {
+			return false
+		}
+		if duration < fp.BeepMinDuration || (fp.BeepMaxDuration > 0 && duration > fp.BeepMaxDuration) {
+			return false
+		}
+		d.identified.BeepMatched = true
+		d.identified.Details += fmt.Sprintf(", beep %.0fHz for %v", beep.Frequency, duration)
+		return true
+	}
+	return false
+}
+
+// Identified returns the platform identified so far, or nil
+func (d *PlatformDetector) Identified() *PlatformEvent {
+	return d.identified
+}
+
+// normalizePrompt lowercases and strips punctuation, since transcripts
+// punctuate canned prompts inconsistently
+func normalizePrompt(text string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'':
+			b.WriteRune(r)
+			space = false
+		case !space:
+			b.WriteRune(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -8,13 +8,15 @@ import (
 )
 
 type SilenceEvent struct {
-	StartTime time.Duration
-	EndTime   time.Duration
-	Duration  time.Duration
-	Confirmed bool
+	StartTime  time.Duration
+	EndTime    time.Duration
+	Duration   time.Duration
+	Confirmed  bool
+	NoiseFloor float64
 }
 
-// SilenceDetector detects silence periods in audio using RMS analysis
+// SilenceDetector detects silence periods in audio using RMS analysis, with a
+// voice activity detector deciding whether loud chunks are actually speech.
 // It distinguishes between brief speech pauses and actual greeting-end silence
 type SilenceDetector struct {
 	config          *config.Config
@@ -27,24 +29,65 @@ type SilenceDetector struct {
 	confirmedEnd        bool
 	lastSpeechTime      time.Duration
 	speechAfterSilence  int
+
+	// Adaptive noise floor (minimum statistics over a sliding window)
+	smoothedPower    float64
+	powerHistory     []float64
+	noiseFloor       float64
+	silenceThreshold float64
+	peakLevel        float64 // loudest chunk so far
+
+	vad *VoiceActivityDetector
+
+	// VoIP artifacts that must not flip the silence state
+	impairments     *ImpairmentDetector
+	impairmentEvent *ImpairmentEvent
+
+	// Inter-phrase pauses observed during the greeting (adaptive confirmation)
+	pauses        []time.Duration
+	confirmWindow time.Duration
 }
 
-func NewSilenceDetector(cfg *config.Config) *SilenceDetector {
+func NewSilenceDetector(cfg *config.Config, sampleRate int) *SilenceDetector {
 	return &SilenceDetector{
 		config:          cfg,
 		silenceStart:    0,
 		inSilence:       false,
 		hadSpeech:       false,
 		speechThreshold: cfg.SilenceThreshold * 3, // Speech is significantly louder than silence
+
+		silenceThreshold: cfg.SilenceThreshold,
+		powerHistory:     make([]float64, 0),
+		vad:              NewVoiceActivityDetector(cfg, sampleRate),
+		impairments:      NewImpairmentDetector(cfg, sampleRate),
+		confirmWindow:    cfg.SilenceConfirmDur,
 	}
 }
 
 func (d *SilenceDetector) Process(chunk audio.AudioChunk) *SilenceEvent {
 	rms := calculateRMS(chunk.Samples)
+	d.updateNoiseFloor(rms, chunk.Duration)
+	d.peakLevel = math.Max(d.peakLevel, rms)
 
-	isSilent := rms < d.config.SilenceThreshold
+	isSilent := rms < d.silenceThreshold
 	isSpeech := rms >= d.speechThreshold
 
+	// Loud but not voice (comfort noise, hum, music, tones) neither counts as
+	// speech nor breaks a silence period
+	if d.config.EnableVAD {
+		isVoice := d.vad.Process(chunk.Samples, !isSilent)
+		isSpeech = isSpeech && isVoice
+		isSilent = isSilent || !isVoice
+	}
+
+	// Packet-loss gaps, replayed frames and comfort noise are never speech and
+	// never break a silence period; comfort noise also starts one
+	d.impairmentEvent = d.impairments.Process(chunk, d.vad.Features(), d.silenceThreshold, d.speechThreshold)
+	if d.impairments.SilenceCompatible() {
+		isSpeech = false
+		isSilent = isSilent || d.inSilence || d.impairments.Current() == ImpairmentComfortNoise
+	}
+
 	currentTime := chunk.Timestamp + chunk.Duration
 
 	if isSpeech {
@@ -74,17 +117,18 @@ func (d *SilenceDetector) Process(chunk audio.AudioChunk) *SilenceEvent {
 				}
 				
 				// Check if silence is sustained long enough to be "confirmed"
-				// Sustained = at least 2 seconds of continuous silence
+				// Sustained = at least SilenceConfirmDur (or the learned window) of continuous silence
 				sustainedDuration := currentTime - d.potentialEndTime
-				if sustainedDuration >= 2*time.Second {
+				if sustainedDuration >= d.confirmWindow {
 					d.confirmedEnd = true
 				}
 				
 				return &SilenceEvent{
-					StartTime: d.silenceStart,
-					EndTime:   currentTime,
-					Duration:  elapsed,
-					Confirmed: d.confirmedEnd,
+					StartTime:  d.silenceStart,
+					EndTime:    currentTime,
+					Duration:   elapsed,
+					Confirmed:  d.confirmedEnd,
+					NoiseFloor: d.noiseFloor,
 				}
 			}
 		}
@@ -92,9 +136,12 @@ func (d *SilenceDetector) Process(chunk audio.AudioChunk) *SilenceEvent {
 		// Sound detected, check if this breaks our silence
 		if d.inSilence {
 			silenceDuration := currentTime - d.silenceStart
-			
-			// If we had a short silence (< 2s) and speech resumed, reset
-			if silenceDuration < 2*time.Second {
+			if d.hadSpeech {
+				d.recordPause(silenceDuration)
+			}
+
+			// If we had a short silence and speech resumed, reset
+			if silenceDuration < d.resetWindow() {
 				d.potentialEndTime = 0
 				d.confirmedEnd = false
 				d.speechAfterSilence = 0
@@ -106,6 +153,119 @@ func (d *SilenceDetector) Process(chunk audio.AudioChunk) *SilenceEvent {
 	return nil
 }
 
+// recordPause learns the speaker's own inter-phrase pause lengths. In adaptive
+// mode the confirmation window becomes a margin above their longest pause, so
+// slow speakers don't trigger early and fast speakers don't wait the full default.
+func (d *SilenceDetector) recordPause(pause time.Duration) {
+	// Shorter gaps are within words/phrases and say nothing about phrasing
+	if pause < 250*time.Millisecond {
+		return
+	}
+	d.pauses = append(d.pauses, pause)
+
+	if !d.config.AdaptiveSilence || len(d.pauses) < 2 {
+		return
+	}
+
+	var longest time.Duration
+	for _, p := range d.pauses {
+		if p > longest {
+			longest = p
+		}
+	}
+
+	window := 2 * longest
+	if window < d.config.AdaptiveSilenceMin {
+		window = d.config.AdaptiveSilenceMin
+	}
+	if window > d.config.AdaptiveSilenceMax {
+		window = d.config.AdaptiveSilenceMax
+	}
+	d.confirmWindow = window
+}
+
+func (d *SilenceDetector) resetWindow() time.Duration {
+	if d.config.AdaptiveSilence {
+		return d.confirmWindow
+	}
+	return d.config.SilenceResetDur
+}
+
+// ConfirmWindow is the sustained silence currently required to confirm the end
+func (d *SilenceDetector) ConfirmWindow() time.Duration {
+	return d.confirmWindow
+}
+
+// Pauses returns the inter-phrase pauses observed so far
+func (d *SilenceDetector) Pauses() []time.Duration {
+	return d.pauses
+}
+
+// updateNoiseFloor tracks the background level with minimum statistics: the
+// noise floor is the minimum smoothed power seen over the last NoiseFloorWindow.
+// Silence and speech thresholds are then set relative to that floor.
+func (d *SilenceDetector) updateNoiseFloor(rms float64, chunkDuration time.Duration) {
+	power := rms * rms
+	if len(d.powerHistory) == 0 {
+		d.smoothedPower = power
+	} else {
+		d.smoothedPower = 0.7*d.smoothedPower + 0.3*power
+	}
+
+	windowLen := 1
+	if chunkDuration > 0 {
+		windowLen = int(d.config.NoiseFloorWindow / chunkDuration)
+	}
+	d.powerHistory = append(d.powerHistory, d.smoothedPower)
+	if len(d.powerHistory) > windowLen {
+		d.powerHistory = d.powerHistory[1:]
+	}
+
+	minPower := d.powerHistory[0]
+	for _, p := range d.powerHistory {
+		if p < minPower {
+			minPower = p
+		}
+	}
+	// The minimum of a fluctuating noise power underestimates its mean
+	d.noiseFloor = math.Sqrt(minPower * 1.5)
+
+	// Keep the fixed thresholds until there's enough history to trust the floor
+	if !d.config.AdaptiveNoiseFloor || len(d.powerHistory) < 10 {
+		return
+	}
+
+	silence := math.Min(d.noiseFloor*d.config.SilenceFloorRatio, d.config.MaxSilenceThreshold)
+	speech := math.Min(d.noiseFloor*d.config.SpeechFloorRatio, d.config.MaxSilenceThreshold*3)
+
+	// On a clean line the floor is near zero - the minimums keep digital hiss from
+	// counting as sound, while quiet recordings can still register as speech
+	d.silenceThreshold = math.Max(silence, d.config.MinSilenceThreshold)
+	d.speechThreshold = math.Max(speech, d.config.MinSpeechThreshold)
+}
+
+// NoiseFloor returns the current background level estimate (RMS)
+func (d *SilenceDetector) NoiseFloor() float64 {
+	return d.noiseFloor
+}
+
+// PeakLevel returns the loudest chunk level (RMS) seen so far
+func (d *SilenceDetector) PeakLevel() float64 {
+	return d.peakLevel
+}
+
+// InspectRaw lets the impairment checks see a chunk before preprocessing and
+// filtering. Call it ahead of Process with the chunk as received.
+func (d *SilenceDetector) InspectRaw(chunk audio.AudioChunk) {
+	d.impairments.Inspect(chunk)
+}
+
+// Impairment returns the stream impairment episode that started on the last
+// processed chunk, if any
+func (d *SilenceDetector) Impairment() *ImpairmentEvent {
+	return d.impairmentEvent
+}
+
 func (d *SilenceDetector) IsInSilence() bool {
 	return d.inSilence
 }
@@ -117,10 +277,27 @@ func (d *SilenceDetector) GetSilenceDuration(currentTime time.Duration) time.Dur
 	return currentTime - d.silenceStart
 }
 
+// VoiceActive reports whether the VAD currently hears speech
+func (d *SilenceDetector) VoiceActive() bool {
+	return d.config.EnableVAD && d.vad.IsActive()
+}
+
 func (d *SilenceDetector) HadSpeech() bool {
 	return d.hadSpeech
 }
 
+func (d *SilenceDetector) LastSpeechTime() time.Duration {
+	return d.lastSpeechTime
+}
+
+// Rearm forgets the greeting end found so far, so a restarted greeting has
+// to earn a new confirmed silence
+func (d *SilenceDetector) Rearm() {
+	d.potentialEndTime = 0
+	d.confirmedEnd = false
+	d.speechAfterSilence = 0
+}
+
 func (d *SilenceDetector) IsConfirmedEnd() bool {
 	return d.confirmedEnd
 }
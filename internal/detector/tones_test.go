@@ -0,0 +1,99 @@
+package detector
+
+import (
+	"math"
+	"time"
+
+	"retape_ai/internal/audio"
+)
+
+const (
+	testSampleRate = 8000
+	testChunk      = 20 * time.Millisecond
+)
+
+// signal is synthesized test audio, built up a segment at a time
+type signal []float64
+
+func samplesIn(d time.Duration) int {
+	return int(d.Seconds() * testSampleRate)
+}
+
+// tone adds d of the sum of the given frequencies, each at amplitude amp
+func (s signal) tone(d time.Duration, amp float64, freqs ...float64) signal {
+	for i := 0; i < samplesIn(d); i++ {
+		t := float64(i) / testSampleRate
+		v := 0.0
+		for _, f := range freqs {
+			v += amp * math.Sin(2*math.Pi*f*t)
+		}
+		s = append(s, v)
+	}
+	return s
+}
+
+// shaped adds d of a tone at freq whose amplitude and phase offset follow
+// envelope and phase over time
+func (s signal) shaped(d time.Duration, freq float64, envelope, phase func(t float64) float64) signal {
+	for i := 0; i < samplesIn(d); i++ {
+		t := float64(i) / testSampleRate
+		s = append(s, envelope(t)*math.Sin(2*math.Pi*freq*t+phase(t)))
+	}
+	return s
+}
+
+// plus mixes other into the start of the signal
+func (s signal) plus(other signal) signal {
+	for i, v := range other {
+		if i < len(s) {
+			s[i] += v
+		}
+	}
+	return s
+}
+
+func (s signal) silence(d time.Duration) signal {
+	return append(s, make([]float64, samplesIn(d))...)
+}
+
+// cadence repeats on/off periods of a tone until total has passed
+func (s signal) cadence(total, on, off time.Duration, amp float64, freqs ...float64) signal {
+	for elapsed := time.Duration(0); elapsed < total; elapsed += on + off {
+		s = s.tone(on, amp, freqs...).silence(off)
+	}
+	return s
+}
+
+// speech adds a voiced harmonic stack with a wandering pitch, modulated into
+// syllables
+func (s signal) speech(d time.Duration) signal {
+	phase := 0.0
+	for i := 0; i < samplesIn(d); i++ {
+		t := float64(i) / testSampleRate
+		phase += 2 * math.Pi * (140 + 20*math.Sin(2*math.Pi*3*t)) / testSampleRate
+		v := 0.0
+		for k := 1; k < 15; k++ {
+			v += 0.3 / float64(k) * math.Sin(float64(k)*phase)
+		}
+		s = append(s, v*(0.2+0.8*math.Abs(math.Sin(2*math.Pi*2.5*t)))*0.4)
+	}
+	return s
+}
+
+// chunks splits the signal into the engine's 20ms chunks
+func (s signal) chunks() []audio.AudioChunk {
+	n := samplesIn(testChunk)
+	var chunks []audio.AudioChunk
+	for i := 0; i+n <= len(s); i += n {
+		chunks = append(chunks, audio.AudioChunk{
+			Samples:   s[i : i+n],
+			Timestamp: time.Duration(i) * time.Second / testSampleRate,
+			Duration:  testChunk,
+		})
+	}
+	return chunks
+}
+
+func ms(n int) time.Duration {
+	return time.Duration(n) * time.Millisecond
+}
@@ -0,0 +1,191 @@
+package detector
+
+import (
+	"math"
+	"math/cmplx"
+
+	"retape_ai/internal/config"
+)
+
+type VADFeatures struct {
+	Energy            float64 // RMS
+	ZeroCrossingRate  float64 // crossings per sample
+	SpectralFlatness  float64 // geometric / arithmetic mean of the power spectrum, 0 = tonal, 1 = white
+	SpeechBandRatio   float64 // share of energy in the 100-3400 Hz voice band
+	PeakConcentration float64 // share of energy in the strongest few bins
+}
+
+// VoiceActivityDetector decides whether a chunk contains human speech rather than
+// just sound. Energy alone can't tell speech from comfort noise, hum, hold music
+// or a beep, so it combines energy with zero-crossing rate, spectral flatness
+// and voice band energy, then smooths the decision with a hangover.
+type VoiceActivityDetector struct {
+	config     *config.Config
+	sampleRate int
+
+	onsetFrames    int
+	hangoverFrames int
+	hangoverLeft   int
+	active         bool
+	lastFeatures   VADFeatures
+}
+
+func NewVoiceActivityDetector(cfg *config.Config, sampleRate int) *VoiceActivityDetector {
+	hangover := 1
+	if cfg.ChunkDuration > 0 {
+		hangover = int(cfg.VADHangover / cfg.ChunkDuration)
+	}
+
+	return &VoiceActivityDetector{
+		config:         cfg,
+		sampleRate:     sampleRate,
+		hangoverFrames: hangover,
+	}
+}
+
+// Process classifies one chunk. loud reports whether the chunk's energy is
+// above the caller's silence threshold; the spectral checks decide whether that
+// energy is actually voice.
+func (v *VoiceActivityDetector) Process(samples []float64, loud bool) bool {
+	features := v.analyze(samples)
+	v.lastFeatures = features
+
+	isVoice := loud && v.looksLikeSpeech(features) && !v.isNetworkTone(samples) && !v.isDTMF(samples)
+
+	if isVoice {
+		v.onsetFrames++
+		// Two consecutive voice frames (40ms) to start, so clicks don't count
+		if v.onsetFrames >= 2 || v.active {
+			v.active = true
+			v.hangoverLeft = v.hangoverFrames
+		}
+		return v.active
+	}
+
+	v.onsetFrames = 0
+	if v.active {
+		if v.hangoverLeft > 0 {
+			v.hangoverLeft--
+			return true
+		}
+		v.active = false
+	}
+	return false
+}
+
+func (v *VoiceActivityDetector) IsActive() bool {
+	return v.active
+}
+
+func (v *VoiceActivityDetector) Features() VADFeatures {
+	return v.lastFeatures
+}
+
+func (v *VoiceActivityDetector) looksLikeSpeech(f VADFeatures) bool {
+	// Hum and other low-frequency noise barely cross zero; hiss crosses constantly
+	if f.ZeroCrossingRate < 0.01 || f.ZeroCrossingRate > v.config.VADMaxZCR {
+		return false
+	}
+	// Comfort noise and hiss have a flat spectrum
+	if f.SpectralFlatness > v.config.VADMaxFlatness {
+		return false
+	}
+	// Beeps and hum put nearly all energy in a couple of bins
+	if f.PeakConcentration > 0.9 {
+		return false
+	}
+	return f.SpeechBandRatio >= v.config.VADMinSpeechBandRatio
+}
+
+// isNetworkTone reports whether a chunk is a busy, reorder, ringback or SIT
+// tone. The dual tones split their energy between two peaks, each with its
+// window leakage, so PeakConcentration alone takes them for voice.
+func (v *VoiceActivityDetector) isNetworkTone(samples []float64) bool {
+	class, _ := classifyTone(samples, v.sampleRate, v.config.NetworkToneMinAmplitude)
+	return class != toneNone
+}
+
+// isDTMF reports whether a chunk is a keypad digit. Like the network tones,
+// its row and column tones pass the single-peak check.
+func (v *VoiceActivityDetector) isDTMF(samples []float64) bool {
+	return v.config.EnableDTMF && IsDTMF(samples, v.sampleRate, v.config)
+}
+
+func (v *VoiceActivityDetector) analyze(samples []float64) VADFeatures {
+	features := VADFeatures{Energy: calculateRMS(samples)}
+	if len(samples) < 2 {
+		return features
+	}
+
+	crossings := 0
+	for i := 1; i < len(samples); i++ {
+		if (samples[i] >= 0) != (samples[i-1] >= 0) {
+			crossings++
+		}
+	}
+	features.ZeroCrossingRate = float64(crossings) / float64(len(samples)-1)
+
+	n := nextPowerOf2(len(samples))
+	padded := make([]complex128, n)
+	for i, s := range samples {
+		window := 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(len(samples)-1)))
+		padded[i] = complex(s*window, 0)
+	}
+	spectrum := computeFFT(padded)
+
+	freqResolution := float64(v.sampleRate) / float64(n)
+	minBin := int(100 / freqResolution)
+	maxBin := int(math.Min(4000, float64(v.sampleRate)/2) / freqResolution)
+	if minBin < 1 {
+		minBin = 1
+	}
+	if maxBin > n/2 {
+		maxBin = n / 2
+	}
+
+	var total, band, logSum float64
+	var top [3]float64
+	count := 0
+	for i := 1; i <= n/2; i++ {
+		power := cmplx.Abs(spectrum[i])
+		power *= power
+		total += power
+
+		freq := float64(i) * freqResolution
+		if freq >= 100 && freq <= 3400 {
+			band += power
+		}
+
+		if i >= minBin && i <= maxBin {
+			logSum += math.Log(power + 1e-12)
+			count++
+
+			// Keep the three strongest bins
+			for k := 0; k < len(top); k++ {
+				if power > top[k] {
+					copy(top[k+1:], top[k:len(top)-1])
+					top[k] = power
+					break
+				}
+			}
+		}
+	}
+
+	if total == 0 || count == 0 {
+		return features
+	}
+
+	var bandTotal float64
+	for i := minBin; i <= maxBin; i++ {
+		p := cmplx.Abs(spectrum[i])
+		bandTotal += p * p
+	}
+
+	features.SpeechBandRatio = band / total
+	if bandTotal > 0 {
+		features.SpectralFlatness = math.Exp(logSum/float64(count)) / (bandTotal / float64(count))
+		features.PeakConcentration = (top[0] + top[1] + top[2]) / bandTotal
+	}
+
+	return features
+}
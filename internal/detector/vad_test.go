@@ -0,0 +1,38 @@
+package detector
+
+import (
+	"testing"
+	"time"
+
+	"retape_ai/internal/config"
+)
+
+func TestVADTones(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     signal
+		voice bool
+	}{
+		{"speech", signal(nil).speech(time.Second), true},
+		{"DTMF digits", signal(nil).tone(ms(200), 0.1, 697, 1209).tone(ms(200), 0.1, 852, 1477).tone(ms(200), 0.1, 941, 1336), false},
+		{"busy", signal(nil).tone(time.Second, 0.1, 480, 620), false},
+		{"ringback", signal(nil).tone(time.Second, 0.1, 440, 480), false},
+		{"SIT", signal(nil).tone(ms(276), 0.1, 913.8).tone(ms(276), 0.1, 1370.6).tone(ms(380), 0.1, 1776.7), false},
+		{"beep", signal(nil).tone(time.Second, 0.2, 1000), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVoiceActivityDetector(config.DefaultConfig(), testSampleRate)
+			var voiced int
+			for _, chunk := range tt.s.chunks() {
+				if v.Process(chunk.Samples, true) {
+					voiced++
+				}
+			}
+			if (voiced > 0) != tt.voice {
+				t.Fatalf("expected voice %v, got %d voiced chunks", tt.voice, voiced)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"retape_ai/internal/detector"
+)
+
+// Roles a beep can play in the stream's beep sequence
+const (
+	BeepRolePending      = "pending"      // still being verified
+	BeepRoleRecord       = "record"       // confirmed, the mailbox records after it
+	BeepRoleSystem       = "system"       // came before any speech, then the personal greeting started
+	BeepRoleIntermediate = "intermediate" // speech resumed after it
+	BeepRoleDoubleFirst  = "double_first" // first half of a double beep
+	BeepRoleSuperseded   = "superseded"   // a later beep took over as the record beep
+	BeepRoleRejected     = "rejected"     // a tonal peak in music, or too weak once verified
+)
+
+// SequencedBeep is a beep from the stream with the role the engine gave it
+type SequencedBeep struct {
+	*detector.BeepEvent
+	Role string
+}
+
+// takeBeep makes a reported beep the current one and returns the transition
+// cause, or "" when the beep was ruled out while it was held
+func (e *DecisionEngine) takeBeep(beep *detector.BeepEvent) string {
+	if e.beepRoles[beep] == BeepRoleRejected {
+		return ""
+	}
+	e.sequenceBeep(beep)
+	e.beepDetected = beep
+	e.beepConfirmedAt = 0
+	details := fmt.Sprintf("freq=%.0fHz, duration=%v, confidence=%.2f",
+		beep.Frequency, beep.EndTime-beep.StartTime, beep.Confidence)
+	if beep.Overlapped {
+		details += ", overlapped by speech"
+	}
+	e.signals = append(e.signals, Signal{
+		Type:      "beep",
+		Timestamp: beep.EndTime,
+		Details:   details,
+	})
+	return fmt.Sprintf("beep at %.0fHz, confidence %.2f", beep.Frequency, beep.Confidence)
+}
+
+// sequenceBeep places a newly reported beep in the sequence: a beep that
+// follows the current one closely is the second half of a double beep, and
+// any later beep takes over as the record beep candidate
+func (e *DecisionEngine) sequenceBeep(beep *detector.BeepEvent) {
+	prev := e.beepDetected
+	if prev == nil {
+		prev = e.droppedBeep
+	}
+	e.secondBeep = false
+	if prev != nil && prev != beep {
+		switch role := e.beepRoles[prev]; {
+		case role != BeepRolePending && role != BeepRoleRecord:
+			// Already ruled out
+		case beep.StartTime-prev.EndTime <= e.config.DoubleBeepGap:
+			e.beepRoles[prev] = BeepRoleDoubleFirst
+			e.secondBeep = true
+			e.signals = append(e.signals, Signal{
+				Type:      "beep",
+				Timestamp: beep.StartTime,
+				Details: fmt.Sprintf("double beep (gap %v) - recording starts after the second",
+					beep.StartTime-prev.EndTime),
+			})
+		default:
+			e.beepRoles[prev] = BeepRoleSuperseded
+		}
+	}
+
+	e.beepRoles[beep] = BeepRolePending
+	// Nothing has been said yet - this may be a system beep ahead of the
+	// personal greeting rather than the record beep
+	e.leadingBeep = !e.silenceDetector.HadSpeech() && !e.phraseFound
+}
+
+// musicBeepGap is how close before music a beep may end and still count as
+// part of it - the first note of a music intro looks like a beep
+const musicBeepGap = 100 * time.Millisecond
+
+// rejectMusicBeeps rules out the beeps inside the music segment from start
+// to end, or ending right before it starts. A beep the music stopped with is
+// left alone - a record beep ends a music bed. It returns true when the beep
+// being verified was one of them.
+func (e *DecisionEngine) rejectMusicBeeps(start, end time.Duration) bool {
+	rejected := false
+	for _, beep := range e.beepDetector.Beeps() {
+		if beep.EndTime+musicBeepGap < start || beep.EndTime+musicBeepGap >= end {
+			continue
+		}
+		switch e.beepRoles[beep] {
+		case BeepRoleRecord, BeepRoleSuperseded, BeepRoleRejected:
+			// Dropped on, replaced already, or ruled out
+			continue
+		}
+		e.beepRoles[beep] = BeepRoleRejected
+		if beep == e.beepDetected {
+			e.signals = append(e.signals, Signal{
+				Type:      "beep",
+				Timestamp: beep.EndTime,
+				Details:   "tonal peak during music, ignoring",
+			})
+			e.beepDetected = nil
+			rejected = true
+		}
+	}
+	return rejected
+}
+
+// maxPairedBeep is the longest tone expected as the second half of a double beep
+const maxPairedBeep = 600 * time.Millisecond
+
+// beepVerifyWait is how long a beep must be followed by quiet to be confirmed.
+// Unless it is already the second half of a double beep, a beep may be the
+// first, so it waits out the gap and the length of a second tone.
+func (e *DecisionEngine) beepVerifyWait() time.Duration {
+	wait := e.waits().BeepVerify.Wait
+	if e.leadingBeep {
+		wait = max(wait, e.config.LeadingBeepVerify)
+	}
+	if !e.secondBeep {
+		wait = max(wait, e.config.DoubleBeepGap+maxPairedBeep)
+	}
+	return wait
+}
+
+// beepSequence returns every beep reported so far with its role
+func (e *DecisionEngine) beepSequence() []SequencedBeep {
+	beeps := e.beepDetector.Beeps()
+	sequence := make([]SequencedBeep, len(beeps))
+	for i, beep := range beeps {
+		role := e.beepRoles[beep]
+		if role == "" {
+			role = BeepRolePending
+		}
+		sequence[i] = SequencedBeep{BeepEvent: beep, Role: role}
+	}
+	return sequence
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"retape_ai/internal/audio"
+	"retape_ai/internal/config"
+)
+
+const testSampleRate = 8000
+
+// synth builds test audio one segment at a time
+type synth struct {
+	samples []float64
+	rng     *rand.Rand
+}
+
+func newSynth() *synth {
+	return &synth{rng: rand.New(rand.NewSource(1))}
+}
+
+func (s *synth) n(d time.Duration) int {
+	return int(d.Seconds() * testSampleRate)
+}
+
+// silence adds line noise
+func (s *synth) silence(d time.Duration) *synth {
+	for i := 0; i < s.n(d); i++ {
+		s.samples = append(s.samples, s.rng.NormFloat64()*0.002)
+	}
+	return s
+}
+
+func (s *synth) tone(freq float64, d time.Duration) *synth {
+	for i := 0; i < s.n(d); i++ {
+		t := float64(i) / testSampleRate
+		s.samples = append(s.samples, 0.2*math.Sin(2*math.Pi*freq*t))
+	}
+	return s
+}
+
+// speech adds a voiced, syllable-modulated harmonic stack with a wandering pitch
+func (s *synth) speech(d time.Duration) *synth {
+	phase := 0.0
+	for i := 0; i < s.n(d); i++ {
+		t := float64(i) / testSampleRate
+		f0 := 140 + 20*math.Sin(2*math.Pi*3*t)
+		phase += 2 * math.Pi * f0 / testSampleRate
+		v := 0.0
+		for k := 1; k < 15; k++ {
+			v += 0.3 / float64(k) * math.Sin(float64(k)*phase)
+		}
+		env := 0.2 + 0.8*math.Abs(math.Sin(2*math.Pi*2.5*t))
+		s.samples = append(s.samples, v*env*0.4+s.rng.NormFloat64()*0.01)
+	}
+	return s
+}
+
+// writeWAV saves the samples and returns the path
+func (s *synth) writeWAV(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.wav")
+	if err := audio.WriteWAV(path, s.samples, testSampleRate); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDoubleBeepRecordsAfterSecond(t *testing.T) {
+	const beepLen = 300 * time.Millisecond
+	for _, gap := range []time.Duration{300 * time.Millisecond, 600 * time.Millisecond} {
+		t.Run(gap.String(), func(t *testing.T) {
+			path := newSynth().
+				silence(300*time.Millisecond).
+				speech(3*time.Second).
+				silence(800*time.Millisecond).
+				tone(1000, beepLen).
+				silence(gap).
+				tone(1000, beepLen).
+				silence(3 * time.Second).
+				writeWAV(t)
+
+			cfg := config.DefaultConfig()
+			cfg.EnableSTT = false
+			result, err := NewDecisionEngine(cfg, testSampleRate).Process(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			secondEnd := 4100*time.Millisecond + beepLen + gap + beepLen
+			if !result.Drops() || absDuration(result.RecommendedDropTime-secondEnd) > 100*time.Millisecond {
+				t.Fatalf("expected a drop after the second beep at %v, got %s at %v (%s)",
+					secondEnd, result.Outcome, result.RecommendedDropTime, result.Reason)
+			}
+			if len(result.DropTimes) != 1 {
+				t.Fatalf("expected one drop, got %v", result.DropTimes)
+			}
+			roles := make([]string, len(result.Beeps))
+			for i, beep := range result.Beeps {
+				roles[i] = beep.Role
+			}
+			if len(roles) != 2 || roles[0] != BeepRoleDoubleFirst || roles[1] != BeepRoleRecord {
+				t.Fatalf("expected roles [%s %s], got %v", BeepRoleDoubleFirst, BeepRoleRecord, roles)
+			}
+		})
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
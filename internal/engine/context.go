@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"retape_ai/internal/config"
+)
+
+// Prior-call outcomes for CallContext.PriorOutcome
+const (
+	PriorBeep   = "beep"    // the last call to this number dropped after a beep
+	PriorNoBeep = "no_beep" // the last call reached a mailbox that never beeped
+)
+
+// nameWindow is how long after the callee's name is heard the personal
+// greeting is taken to still be under way
+const nameWindow = 2 * time.Second
+
+// CallContext is what the dialer already knows about a call before it
+// connects. Every field is an optional hint; the audio still decides.
+type CallContext struct {
+	ExpectedCarrier string // platform name from Config.Platforms
+	PriorOutcome    string // PriorBeep, PriorNoBeep, or "" when unknown
+	ExpectedName    string // the callee's name, as their personal greeting would say it
+}
+
+// Validate checks the hints against the configuration
+func (c CallContext) Validate(cfg *config.Config) error {
+	if c.ExpectedCarrier != "" {
+		names := make([]string, len(cfg.Platforms))
+		known := false
+		for i, fp := range cfg.Platforms {
+			names[i] = fp.Name
+			known = known || fp.Name == c.ExpectedCarrier
+		}
+		if !known {
+			return fmt.Errorf("unknown carrier %q (known: %s)", c.ExpectedCarrier, strings.Join(names, ", "))
+		}
+	}
+	switch c.PriorOutcome {
+	case "", PriorBeep, PriorNoBeep:
+	default:
+		return fmt.Errorf("unknown prior outcome %q (known: %s, %s)", c.PriorOutcome, PriorBeep, PriorNoBeep)
+	}
+	return nil
+}
+
+// SetContext supplies the hints for the call analyzed next
+func (e *DecisionEngine) SetContext(ctx CallContext) {
+	e.context = ctx
+}
+
+// applyContext hands the hints to the detectors before the first chunk
+func (e *DecisionEngine) applyContext() error {
+	if err := e.context.Validate(e.config); err != nil {
+		return err
+	}
+	if e.context.ExpectedCarrier != "" {
+		e.identifyPlatform(e.platform.Expect(e.context.ExpectedCarrier))
+	}
+	if e.context.ExpectedName != "" {
+		e.phraseDetector.ExpectName(e.context.ExpectedName)
+	}
+	return nil
+}
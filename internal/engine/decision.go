@@ -2,6 +2,7 @@ package engine
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -11,62 +12,178 @@ import (
 )
 
 type Signal struct {
-	Type      string // "beep", "silence", "phrase"
+	Type      string // "beep", "beep_candidate", "silence", "phrase", "dtmf", "network_tone", "fax_modem", "music", "clipping", "echo", "hum", "impairment", "platform", "restart"
 	Timestamp time.Duration
 	Details   string
 }
 
+// Outcomes reported in Result.Outcome
+const (
+	OutcomeDrop        = "drop"                // voicemail greeting ended, drop the message
+	OutcomeNetworkTone = "network_tone"        // call failed (SIT/busy/reorder/ringback), do not drop
+	OutcomeFaxModem    = "fax_modem"           // fax machine or modem answered, do not drop
+	OutcomeUnavailable = "mailbox_unavailable" // mailbox full or not set up, do not drop
+
+	// Streams where no speech was ever heard
+	OutcomeBeepOnly       = "beep_only"       // mailbox answered with just a beep, drop after it
+	OutcomeSilentGreeting = "silent_greeting" // live line but no greeting, drop now
+	OutcomeDeadAir        = "dead_air"        // nothing connected, do not drop
+	OutcomeCarrierHold    = "carrier_hold"    // hold music that never gave way to a greeting, do not drop
+)
+
 type Result struct {
+	Outcome             string
+	NetworkTone         string
+	FaxTone             string
+	Platform            string // carrier voicemail platform, if identified
+	Policy              string // decision policy in effect
 	RecommendedDropTime time.Duration
 	Reason              string
 	Signals             []Signal
+	Transitions         []Transition    // every state change, in order
+	DropTimes           []time.Duration // every drop, in order, when monitoring restarted the decision
+	Fit                 *MessageFit     // nil unless message and mailbox lengths are configured
+	Latency             *WaitPlan       // the wait behind the decision and its dead air/risk trade-off
+	Beeps               []SequencedBeep // every beep heard, with its role in the sequence
 	Transcript          string
 	DecisionMadeAt      time.Duration
 	DeadAir             time.Duration
 }
 
+// Drops reports whether the outcome calls for playing the message at
+// RecommendedDropTime
+func (r *Result) Drops() bool {
+	switch r.Outcome {
+	case OutcomeDrop, OutcomeBeepOnly, OutcomeSilentGreeting:
+		return true
+	}
+	return false
+}
+
 const PostBeepVerifyDuration = 500 * time.Millisecond
 
 type DecisionEngine struct {
 	config          *config.Config
+	baseConfig      *config.Config // config before any platform timing overrides
+	context         CallContext
+	preprocessor    *audio.Preprocessor
+	filter          *audio.TelephonyFilter
+	echoCanceller   *audio.EchoCanceller // nil unless a reference signal is supplied
+	reference       *audio.WAVFile
 	beepDetector    *detector.BeepDetector
 	silenceDetector *detector.SilenceDetector
 	phraseDetector  *detector.PhraseDetector
+	dtmfDetector    *detector.DTMFDetector
+	toneClassifier  *detector.NetworkToneClassifier
+	faxDetector     *detector.FaxToneDetector
+	musicDetector   *detector.MusicDetector
+	policy          Policy
+	platform        *detector.PlatformDetector
 	stt             *detector.SpeechToText
 
 	signals         []Signal
 	transcript      string
 	beepDetected    *detector.BeepEvent
 	beepConfirmedAt time.Duration
+	beepRoles       map[*detector.BeepEvent]string // role of every beep so far in the sequence
+	heldBeeps       []*detector.BeepEvent          // reported while a network tone may be under way
+	leadingBeep     bool                           // the current beep came before any speech
+	secondBeep      bool                           // the current beep is the second half of a double beep
 	phraseFound     bool
 	expectsBeep     bool
 	phraseTime      time.Duration
 	firstSilenceAt  time.Duration
+	musicStoppedAt  time.Duration
+
+	voiceOutsideMusic time.Duration // voice activity with no music playing, for telling hold from greetings
+	lastClipAt        time.Duration
+	echoActive        bool
 
-	decisionMade   bool
+	machine        *greetingMachine
 	decisionResult *Result
+
+	// Post-drop monitoring
+	drops         []time.Duration
+	droppedBeep   *detector.BeepEvent // beep the last drop was based on
+	monitorSpeech time.Duration       // continuous speech since the drop
+	monitorDone   bool
 }
 
 func NewDecisionEngine(cfg *config.Config, sampleRate int) *DecisionEngine {
 	return &DecisionEngine{
 		config:          cfg,
+		baseConfig:      cfg,
+		preprocessor:    audio.NewPreprocessor(cfg, sampleRate),
+		filter:          audio.NewTelephonyFilter(cfg, sampleRate),
 		beepDetector:    detector.NewBeepDetector(cfg, sampleRate),
-		silenceDetector: detector.NewSilenceDetector(cfg),
+		silenceDetector: detector.NewSilenceDetector(cfg, sampleRate),
 		phraseDetector:  detector.NewPhraseDetector(cfg),
+		dtmfDetector:    detector.NewDTMFDetector(cfg, sampleRate),
+		toneClassifier:  detector.NewNetworkToneClassifier(cfg, sampleRate),
+		faxDetector:     detector.NewFaxToneDetector(cfg, sampleRate),
+		musicDetector:   detector.NewMusicDetector(cfg, sampleRate),
+		platform:        detector.NewPlatformDetector(cfg),
 		stt:             detector.NewSpeechToText(cfg, sampleRate),
 		signals:         make([]Signal, 0),
+		beepRoles:       make(map[*detector.BeepEvent]string),
+		machine:         newGreetingMachine(),
 	}
 }
 
+// SetPolicy decides this call with p instead of the policy named by
+// DecisionPolicy
+func (e *DecisionEngine) SetPolicy(p Policy) {
+	e.policy = p
+}
+
 func (e *DecisionEngine) Process(filePath string) (*Result, error) {
+	return e.ProcessWithReference(filePath, "")
+}
+
+// ProcessWithReference analyzes filePath with referencePath as our own
+// outbound audio, which is cancelled out of the received channel before
+// detection. An empty referencePath disables echo cancellation.
+func (e *DecisionEngine) ProcessWithReference(filePath, referencePath string) (*Result, error) {
+	if e.policy == nil {
+		policy, err := NewPolicy(e.config.DecisionPolicy, e.config)
+		if err != nil {
+			return nil, err
+		}
+		e.policy = policy
+	}
+
 	streamer, err := audio.NewStreamer(filePath, e.config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create streamer: %w", err)
 	}
 
 	sampleRate := streamer.SampleRate()
+	e.echoCanceller = nil
+	if referencePath != "" {
+		ref, err := audio.OpenWAV(referencePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open reference: %w", err)
+		}
+		defer ref.Close()
+		if ref.SampleRate() != sampleRate {
+			return nil, fmt.Errorf("reference sample rate %d Hz does not match %d Hz", ref.SampleRate(), sampleRate)
+		}
+		e.reference = ref
+		e.echoCanceller = audio.NewEchoCanceller(e.config, sampleRate)
+	}
+	e.preprocessor = audio.NewPreprocessor(e.config, sampleRate)
+	e.filter = audio.NewTelephonyFilter(e.config, sampleRate)
 	e.beepDetector = detector.NewBeepDetector(e.config, sampleRate)
+	e.silenceDetector = detector.NewSilenceDetector(e.config, sampleRate)
+	e.dtmfDetector = detector.NewDTMFDetector(e.config, sampleRate)
+	e.toneClassifier = detector.NewNetworkToneClassifier(e.config, sampleRate)
+	e.faxDetector = detector.NewFaxToneDetector(e.config, sampleRate)
+	e.musicDetector = detector.NewMusicDetector(e.config, sampleRate)
+	e.platform = detector.NewPlatformDetector(e.config)
 	e.stt = detector.NewSpeechToText(e.config, sampleRate)
+	if err := e.applyContext(); err != nil {
+		return nil, err
+	}
 
 	sttEnabled := false
 	if e.stt.IsEnabled() {
@@ -75,7 +192,6 @@ func (e *DecisionEngine) Process(filePath string) (*Result, error) {
 		} else {
 			sttEnabled = true
 			defer e.stt.Close()
-			go e.processTranscripts()
 		}
 	}
 
@@ -84,44 +200,218 @@ func (e *DecisionEngine) Process(filePath string) (*Result, error) {
 		lastChunkTime = chunk.Timestamp + chunk.Duration
 
 		e.processChunk(chunk, sttEnabled)
+		if sttEnabled {
+			e.drainTranscripts(0)
+		}
 
-		if e.decisionMade {
-			break
+		if e.machine.state == StateDecided {
+			if !e.monitor(lastChunkTime, chunk.Duration) {
+				break
+			}
+			continue
 		}
 
 		e.checkForDecision(lastChunkTime)
 	}
 
 	if sttEnabled {
-		time.Sleep(2 * time.Second) // Give Deepgram time to process
+		e.drainTranscripts(2 * time.Second) // Give Deepgram time to process
 	}
 
-	if !e.decisionMade {
+	if e.machine.state != StateDecided {
 		e.makeFinalDecision(lastChunkTime)
 	}
+	// Include what monitoring saw after the decision
+	e.decisionResult.Signals = e.signals
+	e.decisionResult.Transitions = e.machine.transitions
+	e.decisionResult.Beeps = e.beepSequence()
 
 	return e.decisionResult, nil
 }
 
 func (e *DecisionEngine) processChunk(chunk audio.AudioChunk, sttEnabled bool) {
-	if beepEvent := e.beepDetector.Process(chunk); beepEvent != nil {
-		e.beepDetected = beepEvent
-		e.beepConfirmedAt = 0
+	// Packet loss artifacts are sample-exact and only recognizable as received
+	e.silenceDetector.InspectRaw(chunk)
+
+	chunk, stats := e.preprocessor.Process(chunk)
+	if stats.Clipped {
+		// One signal per clipping episode rather than per chunk
+		if e.lastClipAt == 0 || chunk.Timestamp-e.lastClipAt > time.Second {
+			e.signals = append(e.signals, Signal{
+				Type:      "clipping",
+				Timestamp: chunk.Timestamp,
+				Details:   fmt.Sprintf("%d samples at full scale, harmonics may look tonal", stats.ClippedSamples),
+			})
+		}
+		e.lastClipAt = chunk.Timestamp
+	}
+
+	if e.echoCanceller != nil {
+		// A short read at the end of the reference just means our side went quiet
+		reference, _ := e.reference.ReadSamples(len(chunk.Samples))
+		var echo audio.EchoStats
+		chunk, echo = e.echoCanceller.Process(chunk, reference)
+		if echo.EchoPresent && !e.echoActive {
+			e.signals = append(e.signals, Signal{
+				Type:      "echo",
+				Timestamp: chunk.Timestamp,
+				Details:   fmt.Sprintf("outbound audio bleeding into received channel, cancelling (ERLE %.1f dB)", echo.ERLE),
+			})
+		}
+		e.echoActive = echo.EchoPresent || (e.echoActive && echo.ReferenceActive)
+	}
+
+	// STT is trained on wideband speech, so by default it keeps the unfiltered audio
+	sttSamples := chunk.Samples
+	chunk, humEvent := e.filter.Process(chunk)
+	if humEvent != nil {
 		e.signals = append(e.signals, Signal{
-			Type:      "beep",
-			Timestamp: beepEvent.EndTime,
-			Details:   fmt.Sprintf("freq=%.0fHz, duration=%v", beepEvent.Frequency, beepEvent.EndTime-beepEvent.StartTime),
+			Type:      "hum",
+			Timestamp: chunk.Timestamp,
+			Details:   humEvent.String(),
 		})
 	}
+	if e.config.FilterSTTInput {
+		sttSamples = chunk.Samples
+	}
+
+	// Call-progress tones mean the call never reached a mailbox - terminal, no drop
+	if toneEvent := e.toneClassifier.Process(chunk); toneEvent != nil {
+		e.signals = append(e.signals, Signal{
+			Type:      "network_tone",
+			Timestamp: toneEvent.StartTime,
+			Details:   fmt.Sprintf("%s (%s)", toneEvent.Type, toneEvent.Details),
+		})
+		if e.machine.state == StateDecided {
+			// The line moved on after the drop - nothing left to monitor
+			e.monitorDone = true
+			return
+		}
+		e.makeNoDropDecision(
+			OutcomeNetworkTone,
+			fmt.Sprintf("Network tone detected (%s) - call did not reach voicemail, not dropping", toneEvent.Type),
+			toneEvent.EndTime,
+		)
+		e.decisionResult.NetworkTone = toneEvent.Type
+		for _, beep := range e.heldBeeps {
+			e.beepRoles[beep] = BeepRoleRejected
+		}
+		return
+	}
+
+	// Never leave a voicemail on a fax line
+	if faxEvent := e.faxDetector.Process(chunk); faxEvent != nil {
+		e.signals = append(e.signals, Signal{
+			Type:      "fax_modem",
+			Timestamp: faxEvent.StartTime,
+			Details:   fmt.Sprintf("%s (%s)", faxEvent.Type, faxEvent.Details),
+		})
+		// A confirmed beep outranks a lone CNG-length burst, so the beep we
+		// dropped on may have been the first burst of this cadence
+		cngBeep := faxEvent.Type == detector.ToneFaxCNG && e.droppedBeep != nil &&
+			e.droppedBeep.StartTime >= faxEvent.StartTime
+		if e.machine.state == StateDecided && !cngBeep {
+			// The line moved on after the drop - nothing left to monitor
+			e.monitorDone = true
+			return
+		}
+		e.makeNoDropDecision(
+			OutcomeFaxModem,
+			fmt.Sprintf("Fax/modem tone detected (%s) - not dropping", faxEvent.Type),
+			faxEvent.EndTime,
+		)
+		e.decisionResult.FaxTone = faxEvent.Type
+		return
+	}
+
+	if dtmfEvent := e.dtmfDetector.Process(chunk); dtmfEvent != nil {
+		e.signals = append(e.signals, Signal{
+			Type:      "dtmf",
+			Timestamp: dtmfEvent.StartTime,
+			Details:   fmt.Sprintf("digit=%c, duration=%v", dtmfEvent.Digit, dtmfEvent.EndTime-dtmfEvent.StartTime),
+		})
+	}
+
+	// Explains this chunk's state transition, if any
+	var cause string
+
+	if musicEvent := e.musicDetector.Process(chunk); musicEvent != nil {
+		if musicEvent.Playing {
+			e.musicStoppedAt = 0
+			e.signals = append(e.signals, Signal{
+				Type:      "music",
+				Timestamp: musicEvent.StartTime,
+				Details:   fmt.Sprintf("music started, score=%.2f", musicEvent.Score),
+			})
+			// Music is detected a MusicWindow after it starts - beeps reported
+			// in the meantime were notes in it
+			if e.rejectMusicBeeps(musicEvent.StartTime, math.MaxInt64) {
+				cause = "beep was a tonal peak in music"
+			}
+		} else {
+			details := fmt.Sprintf("music stopped, played %v", musicEvent.EndTime-musicEvent.StartTime)
+			if e.heardGreeting() {
+				e.musicStoppedAt = musicEvent.EndTime
+			} else {
+				// A music intro stopping is not the end of a greeting that hasn't started
+				details += ", no speech yet - not an end cue"
+			}
+			e.signals = append(e.signals, Signal{
+				Type:      "music",
+				Timestamp: musicEvent.EndTime,
+				Details:   details,
+			})
+			if e.rejectMusicBeeps(musicEvent.StartTime, musicEvent.EndTime) {
+				cause = "beep was a tonal peak in music"
+			}
+		}
+	}
+	if e.silenceDetector.VoiceActive() && !e.musicDetector.IsPlaying() {
+		e.voiceOutsideMusic += chunk.Duration
+	}
+
+	if beepEvent := e.beepDetector.Process(chunk); beepEvent != nil && beepEvent.NearMiss {
+		// Notes in hold music are near misses by the dozen - only report them outside music
+		if !e.musicDetector.IsPlaying() {
+			e.signals = append(e.signals, Signal{
+				Type:      "beep_candidate",
+				Timestamp: beepEvent.EndTime,
+				Details: fmt.Sprintf("near miss (%s), freq=%.0fHz, duration=%v, confidence=%.2f",
+					beepEvent.MissReason, beepEvent.Frequency, beepEvent.EndTime-beepEvent.StartTime, beepEvent.Confidence),
+			})
+		}
+	} else if beepEvent != nil {
+		// A SIT or busy segment looks like a beep until the classifier rules on it
+		e.heldBeeps = append(e.heldBeeps, beepEvent)
+	}
+	if len(e.heldBeeps) > 0 && !e.toneClassifier.Pending() {
+		for _, beep := range e.heldBeeps {
+			if held := e.takeBeep(beep); held != "" {
+				cause = held
+			}
+		}
+		e.heldBeeps = e.heldBeeps[:0]
+	}
 
 	silenceEvent := e.silenceDetector.Process(chunk)
+	if e.silenceDetector.VoiceActive() {
+		// Someone answered - rings so far don't count toward no-answer
+		e.toneClassifier.Reset()
+	}
+	if impairment := e.silenceDetector.Impairment(); impairment != nil {
+		e.signals = append(e.signals, Signal{
+			Type:      "impairment",
+			Timestamp: impairment.StartTime,
+			Details:   fmt.Sprintf("%s: %s, treated as silence", impairment.Type, impairment.Details),
+		})
+	}
 	if silenceEvent != nil {
 		if silenceEvent.Confirmed && e.firstSilenceAt == 0 {
 			e.firstSilenceAt = silenceEvent.StartTime
 			e.signals = append(e.signals, Signal{
 				Type:      "silence",
 				Timestamp: silenceEvent.StartTime,
-				Details:   fmt.Sprintf("confirmed silence, duration=%v", silenceEvent.Duration),
+				Details:   fmt.Sprintf("confirmed silence, duration=%v, noise floor=%.4f", silenceEvent.Duration, silenceEvent.NoiseFloor),
 			})
 		}
 	}
@@ -129,122 +419,210 @@ func (e *DecisionEngine) processChunk(chunk audio.AudioChunk, sttEnabled bool) {
 	// Check if speech resumed after a beep (=> intermediate beep)
 	if e.beepDetected != nil && e.beepConfirmedAt == 0 {
 		timeSinceBeep := chunk.Timestamp - e.beepDetected.EndTime
+		verify := e.beepVerifyWait()
 
 		// If silence detector indicates speech is happening, reset the beep
-		if timeSinceBeep > 0 && timeSinceBeep < PostBeepVerifyDuration {
-			if silenceEvent == nil && !e.silenceDetector.IsInSilence() {
+		if timeSinceBeep > 0 && timeSinceBeep < verify {
+			if e.musicDetector.IsSounding() {
+				// Music carried on past the "beep" - it was a tonal peak in the music
 				e.signals = append(e.signals, Signal{
 					Type:      "beep",
 					Timestamp: chunk.Timestamp,
-					Details:   "intermediate beep - speech resumed, ignoring",
+					Details:   "tonal peak during music, ignoring",
 				})
+				cause = "beep was a tonal peak in music"
+				e.beepRoles[e.beepDetected] = BeepRoleRejected
+				e.beepDetected = nil
+			} else if silenceEvent == nil && !e.silenceDetector.IsInSilence() {
+				if e.leadingBeep {
+					e.beepRoles[e.beepDetected] = BeepRoleSystem
+					e.signals = append(e.signals, Signal{
+						Type:      "beep",
+						Timestamp: chunk.Timestamp,
+						Details:   "system beep before the greeting - speech followed, waiting for the record beep",
+					})
+				} else {
+					e.beepRoles[e.beepDetected] = BeepRoleIntermediate
+					e.signals = append(e.signals, Signal{
+						Type:      "beep",
+						Timestamp: chunk.Timestamp,
+						Details:   "intermediate beep - speech resumed, ignoring",
+					})
+				}
+				cause = "speech resumed after beep"
 				e.beepDetected = nil
 			}
-		} else if timeSinceBeep >= PostBeepVerifyDuration {
-			// Verify period passed with no speech - confirm this beep
-			e.beepConfirmedAt = chunk.Timestamp
+		} else if timeSinceBeep >= verify && !e.beepDetector.IsTracking() {
+			// A tone still sounding may be the second half of a double beep
+			// Verify period passed with no speech - confirm this beep unless the
+			// evidence, now including the audio after it, is too weak
+			if e.beepDetected.Confidence < e.config.BeepMinConfidence {
+				e.signals = append(e.signals, Signal{
+					Type:      "beep",
+					Timestamp: chunk.Timestamp,
+					Details:   fmt.Sprintf("low confidence beep (%.2f), ignoring", e.beepDetected.Confidence),
+				})
+				cause = "beep confidence too low"
+				e.beepRoles[e.beepDetected] = BeepRoleRejected
+				e.beepDetected = nil
+			} else {
+				e.beepConfirmedAt = chunk.Timestamp
+				e.beepRoles[e.beepDetected] = BeepRoleRecord
+				if e.platform.ProcessBeep(e.beepDetected) {
+					e.signals = append(e.signals, Signal{
+						Type:      "platform",
+						Timestamp: e.beepDetected.EndTime,
+						Details:   fmt.Sprintf("beep matches the %s fingerprint", e.platformName()),
+					})
+				}
+			}
 		}
 	}
 
+	e.updateState(chunk.Timestamp+chunk.Duration, cause)
+
 	if sttEnabled {
-		e.stt.SendAudio(chunk.Samples)
+		e.stt.SendAudio(sttSamples)
 	}
 }
 
 func (e *DecisionEngine) checkForDecision(currentTime time.Duration) {
-	// Priority 1: Beep detected AND confirmed (verify period passed)
-	if e.beepDetected != nil && e.beepConfirmedAt > 0 {
-		e.makeDecision(
-			e.beepDetected.EndTime+50*time.Millisecond,
-			"Beep detected and confirmed (no speech resumed) - dropping after beep",
-			currentTime,
-		)
+	// Tones that may still turn out to be SIT/busy/reorder or fax must not be taken as beeps.
+	// A lone CNG-length 1100 Hz burst is also what an ordinary record beep looks
+	// like: after a greeting the confirmed beep wins, but with the beep as the
+	// only evidence the wait for a second burst runs out the cadence's off period.
+	faxPending := e.faxDetector.Pending() &&
+		(e.beepConfirmedAt == 0 || !e.silenceDetector.HadSpeech() && !e.phraseFound)
+	if e.toneClassifier.Pending() || faxPending {
 		return
 	}
 
-	// Priority 2: End phrase detected + confirmed silence = drop quickly
-	if e.phraseFound && e.firstSilenceAt > 0 && !e.expectsBeep {
-		// Phrase found, silence confirmed, no beep expected - drop after 1s wait
-		timeSinceSilence := currentTime - e.firstSilenceAt
-		if timeSinceSilence >= 1*time.Second {
-			e.makeDecision(
-				e.firstSilenceAt+200*time.Millisecond,
-				"End phrase + silence detected (no beep expected) - dropping",
-				e.firstSilenceAt+1*time.Second,
-			)
-			return
-		}
+	if decision := e.policy.Decide(e.state(currentTime), currentTime); decision != nil {
+		e.makeDecision(decision.DropTime, decision.Reason, decision.DecisionTime)
+		e.decisionResult.Latency = decision.Plan
+		return
 	}
+	e.noSpeech(currentTime, false)
+}
 
-	// Priority 3: Phrase indicates beep is coming - wait longer for beep
-	if e.expectsBeep && e.firstSilenceAt > 0 {
-		timeSinceSilence := currentTime - e.firstSilenceAt
-		// Wait up to 5 seconds for beep when phrase says "after the beep"
-		if timeSinceSilence >= 5*time.Second {
-			e.makeDecision(
-				e.firstSilenceAt+200*time.Millisecond,
-				"Phrase indicated beep expected, waited 5s - dropping",
-				e.firstSilenceAt+5*time.Second,
-			)
-			return
-		}
+// state snapshots what the detectors have found so far for the policy
+func (e *DecisionEngine) state(currentTime time.Duration) *State {
+	return &State{
+		Config:          e.config,
+		Signals:         e.signals,
+		Phase:           e.machine.state,
+		Beep:            e.beepDetected,
+		BeepConfirmedAt: e.beepConfirmedAt,
+		BeepTracking:    e.beepDetector.IsTracking(),
+		PhraseFound:     e.phraseFound,
+		ExpectsBeep:     e.expectsBeep,
+		Phrase:          e.phraseDetector.GetDetected(),
+		FirstSilenceAt:  e.firstSilenceAt,
+		InSilence:       e.silenceDetector.IsInSilence(),
+		SilenceDuration: e.silenceDetector.GetSilenceDuration(currentTime),
+		VoiceActive:     e.silenceDetector.VoiceActive(),
+		HadSpeech:       e.silenceDetector.HadSpeech(),
+		LastSpeechTime:  e.silenceDetector.LastSpeechTime(),
+		ConfirmWindow:   e.silenceDetector.ConfirmWindow(),
+		MusicStoppedAt:  e.musicStoppedAt,
+		Platform:        e.platformName(),
+		Context:         e.context,
+		Name:            e.phraseDetector.NameDetected(),
+		Beeps:           e.beepSequence(),
+		Waits:           e.waits(),
 	}
+}
 
-	// Priority 4: Confirmed silence + timeout expired (no phrase indicating beep)
-	// Skip this if we expect a beep - let Priority 3 handle the longer wait
-	if e.firstSilenceAt > 0 && e.silenceDetector.HadSpeech() && !e.expectsBeep {
-		timeSinceSilence := currentTime - e.firstSilenceAt
-		if timeSinceSilence >= e.config.BeepWaitTimeout {
-			e.makeDecision(
-				e.firstSilenceAt+200*time.Millisecond,
-				fmt.Sprintf("Confirmed silence, waited %.1fs for beep - dropping", e.config.BeepWaitTimeout.Seconds()),
-				e.firstSilenceAt+e.config.BeepWaitTimeout,
-			)
-			return
-		}
+// identifyPlatform records an identified carrier platform and switches to its
+// timing overrides for the rest of the call
+func (e *DecisionEngine) identifyPlatform(event *detector.PlatformEvent) {
+	if event == nil {
+		return
+	}
+	// A later identification replaces an expected platform, timing included
+	e.config = e.baseConfig.WithTiming(event.Timing)
+	e.signals = append(e.signals, Signal{
+		Type:      "platform",
+		Timestamp: event.Timestamp,
+		Details:   fmt.Sprintf("%s (%s)", event.Platform, event.Details),
+	})
+}
+
+func (e *DecisionEngine) platformName() string {
+	if event := e.platform.Identified(); event != nil {
+		return event.Platform
 	}
+	return ""
 }
 
 func (e *DecisionEngine) makeDecision(dropTime time.Duration, reason string, decisionTime time.Duration) {
-	e.decisionMade = true
+	e.machine.to(StateDecided, decisionTime, reason)
+	e.drops = append(e.drops, dropTime)
+	e.droppedBeep = e.beepDetected
+	e.monitorSpeech = 0
 
 	var deadAir time.Duration
-	if e.firstSilenceAt > 0 {
+	if e.beepDetected != nil && e.beepConfirmedAt > 0 {
+		deadAir = decisionTime - e.beepDetected.EndTime
+	} else if e.firstSilenceAt > 0 {
 		deadAir = decisionTime - e.firstSilenceAt
 	} else if e.beepDetected != nil {
 		deadAir = decisionTime - e.beepDetected.EndTime
 	}
 
+	outcome := OutcomeDrop
+	if e.beepDetected != nil && !e.silenceDetector.HadSpeech() && !e.phraseFound {
+		outcome = OutcomeBeepOnly
+	}
+
 	e.decisionResult = &Result{
+		Outcome:             outcome,
+		Platform:            e.platformName(),
+		Policy:              e.policy.Name(),
 		RecommendedDropTime: dropTime,
+		Fit:                 e.messageFit(dropTime),
 		Reason:              reason,
 		Signals:             e.signals,
+		Transitions:         e.machine.transitions,
+		DropTimes:           e.drops,
 		Transcript:          e.transcript,
 		DecisionMadeAt:      decisionTime,
 		DeadAir:             deadAir,
 	}
 }
 
+// makeNoDropDecision ends processing with an outcome where no message should be played
+func (e *DecisionEngine) makeNoDropDecision(outcome string, reason string, decisionTime time.Duration) {
+	e.machine.to(StateDecided, decisionTime, reason)
+
+	e.decisionResult = &Result{
+		Outcome:        outcome,
+		Platform:       e.platformName(),
+		Policy:         e.policy.Name(),
+		Reason:         reason,
+		Signals:        e.signals,
+		Transitions:    e.machine.transitions,
+		Transcript:     e.transcript,
+		DecisionMadeAt: decisionTime,
+	}
+}
+
 func (e *DecisionEngine) makeFinalDecision(totalDuration time.Duration) {
-	var dropTime time.Duration
-	var reason string
-
-	if e.beepDetected != nil {
-		dropTime = e.beepDetected.EndTime + 50*time.Millisecond
-		reason = "Beep detected at end - dropping after beep"
-	} else if e.firstSilenceAt > 0 && e.silenceDetector.HadSpeech() {
-		dropTime = e.firstSilenceAt + 200*time.Millisecond
-		reason = "Silence after speech - no beep detected"
-	} else if e.phraseFound {
-		phraseEvent := e.phraseDetector.GetDetected()
-		if phraseEvent != nil {
-			dropTime = phraseEvent.Timestamp + 1*time.Second
-			reason = "End phrase detected"
-		}
+	if e.noSpeech(totalDuration, true) {
+		return
+	}
+
+	decision := e.policy.Final(e.state(totalDuration), totalDuration)
+
+	// Without any signal the drop time is a guess - make it one the message fits
+	var fit *MessageFit
+	if e.beepDetected == nil && e.firstSilenceAt == 0 && !e.phraseFound {
+		fit = e.fitFallback(&decision)
 	} else {
-		dropTime = time.Duration(float64(totalDuration) * 0.9)
-		reason = "No clear signal - using fallback (90% of duration)"
+		fit = e.messageFit(decision.DropTime)
 	}
+	e.machine.to(StateDecided, decision.DecisionTime, decision.Reason)
+	e.drops = append(e.drops, decision.DropTime)
 
 	var deadAir time.Duration
 	if e.firstSilenceAt > 0 {
@@ -252,38 +630,98 @@ func (e *DecisionEngine) makeFinalDecision(totalDuration time.Duration) {
 	}
 
 	e.decisionResult = &Result{
-		RecommendedDropTime: dropTime,
-		Reason:              reason,
+		Outcome:             OutcomeDrop,
+		Platform:            e.platformName(),
+		Policy:              e.policy.Name(),
+		RecommendedDropTime: decision.DropTime,
+		Fit:                 fit,
+		Reason:              decision.Reason,
 		Signals:             e.signals,
+		Transitions:         e.machine.transitions,
+		DropTimes:           e.drops,
 		Transcript:          e.transcript,
-		DecisionMadeAt:      totalDuration,
+		DecisionMadeAt:      decision.DecisionTime,
 		DeadAir:             deadAir,
 	}
 }
 
-func (e *DecisionEngine) processTranscripts() {
-	for event := range e.stt.Results() {
+// drainTranscripts applies the transcripts STT has delivered, waiting up to
+// wait for more. Transcripts arrive on the STT client's goroutine but are
+// applied here, on the processing goroutine, so engine state has one writer.
+func (e *DecisionEngine) drainTranscripts(wait time.Duration) {
+	if wait == 0 {
+		for {
+			select {
+			case event, ok := <-e.stt.Results():
+				if !ok {
+					return
+				}
+				e.processTranscript(event)
+			default:
+				return
+			}
+		}
+	}
 
-		if event.IsFinal {
-			e.transcript += " " + event.Text
+	timeout := time.After(wait)
+	for {
+		select {
+		case event, ok := <-e.stt.Results():
+			if !ok {
+				return
+			}
+			e.processTranscript(event)
+		case <-timeout:
+			return
 		}
+	}
+}
 
-		if phraseEvent := e.phraseDetector.Process(event.Text, event.Timestamp); phraseEvent != nil {
-			if !e.phraseFound {
-				e.phraseFound = true
-				e.phraseTime = phraseEvent.Timestamp
+func (e *DecisionEngine) processTranscript(event detector.TranscriptEvent) {
+	if event.IsFinal {
+		e.transcript += " " + event.Text
+	}
 
-				phrase := strings.ToLower(phraseEvent.Phrase)
-				if strings.Contains(phrase, "beep") || strings.Contains(phrase, "tone") {
-					e.expectsBeep = true
-				}
+	e.identifyPlatform(e.platform.ProcessTranscript(event.Text, event.Timestamp))
 
-				e.signals = append(e.signals, Signal{
-					Type:      "phrase",
-					Timestamp: phraseEvent.Timestamp,
-					Details:   fmt.Sprintf("matched: '%s'", phraseEvent.Phrase),
-				})
+	// A mailbox that cannot take a message will never beep or record
+	if unavailable := e.phraseDetector.ProcessUnavailable(event.Text, event.Timestamp); unavailable != nil &&
+		e.machine.state != StateDecided {
+		e.signals = append(e.signals, Signal{
+			Type:      "phrase",
+			Timestamp: unavailable.Timestamp,
+			Details:   fmt.Sprintf("matched: '%s' (%s)", unavailable.Phrase, unavailable.Category),
+		})
+		e.makeNoDropDecision(
+			OutcomeUnavailable,
+			fmt.Sprintf("Mailbox cannot take a message ('%s') - not dropping", unavailable.Phrase),
+			unavailable.Timestamp,
+		)
+		return
+	}
+
+	if nameEvent := e.phraseDetector.ProcessName(event.Text, event.Timestamp); nameEvent != nil {
+		e.signals = append(e.signals, Signal{
+			Type:      "phrase",
+			Timestamp: nameEvent.Timestamp,
+			Details:   fmt.Sprintf("personal greeting: '%s' (%s)", nameEvent.Phrase, nameEvent.Category),
+		})
+	}
+
+	if phraseEvent := e.phraseDetector.Process(event.Text, event.Timestamp); phraseEvent != nil {
+		if !e.phraseFound {
+			e.phraseFound = true
+			e.phraseTime = phraseEvent.Timestamp
+
+			if phraseEvent.Category == detector.PhraseBeepCue {
+				e.expectsBeep = true
 			}
+
+			e.signals = append(e.signals, Signal{
+				Type:      "phrase",
+				Timestamp: phraseEvent.Timestamp,
+				Details:   fmt.Sprintf("matched: '%s' (%s)", phraseEvent.Phrase, phraseEvent.Category),
+			})
 		}
 	}
 }
@@ -303,6 +741,20 @@ func FormatResult(filename string, result *Result) string {
 		output += "Detected signals: None\n"
 	}
 
+	if len(result.Transitions) > 0 {
+		output += "State transitions:\n"
+		for _, t := range result.Transitions {
+			output += fmt.Sprintf("  - %.2fs %s -> %s (%s)\n", t.Timestamp.Seconds(), t.From, t.To, t.Cause)
+		}
+	}
+
+	if len(result.Beeps) > 1 {
+		output += "Beep sequence:\n"
+		for _, b := range result.Beeps {
+			output += fmt.Sprintf("  - %.2fs %.0fHz %s\n", b.EndTime.Seconds(), b.Frequency, b.Role)
+		}
+	}
+
 	if result.Transcript != "" {
 		transcript := result.Transcript
 		if len(transcript) > 100 {
@@ -311,9 +763,38 @@ func FormatResult(filename string, result *Result) string {
 		output += fmt.Sprintf("Transcript: %s\n", transcript)
 	}
 
+	if result.Platform != "" {
+		output += fmt.Sprintf("Platform: %s\n", result.Platform)
+	}
+
+	if !result.Drops() {
+		output += fmt.Sprintf("\n✗ No drop: %s\n", result.Outcome)
+		output += fmt.Sprintf("  Reason: %s\n", result.Reason)
+		output += fmt.Sprintf("  Decision made at: %.2fs into stream\n", result.DecisionMadeAt.Seconds())
+		return output
+	}
+
 	output += fmt.Sprintf("\n✓ Ideal drop time: %.2fs\n", result.RecommendedDropTime.Seconds())
+	if result.Outcome != OutcomeDrop {
+		output += fmt.Sprintf("  Outcome: %s\n", result.Outcome)
+	}
+	if len(result.DropTimes) > 1 {
+		earlier := make([]string, len(result.DropTimes)-1)
+		for i, t := range result.DropTimes[:len(result.DropTimes)-1] {
+			earlier[i] = fmt.Sprintf("%.2fs", t.Seconds())
+		}
+		output += fmt.Sprintf("  Earlier drops (restarted): %s\n", strings.Join(earlier, ", "))
+	}
 	output += fmt.Sprintf("  Reason: %s\n", result.Reason)
 	output += fmt.Sprintf("  Decision made at: %.2fs into stream\n", result.DecisionMadeAt.Seconds())
+	if plan := result.Latency; plan != nil && plan.Budget > 0 {
+		output += fmt.Sprintf("  Latency: waited %.2fs (default %.2fs, budget %.2fs), confidence %.2f, early-drop risk %.1f%%\n",
+			plan.Wait.Seconds(), plan.Default.Seconds(), plan.Budget.Seconds(), plan.Confidence, 100*plan.EarlyDropRisk)
+	}
+	if fit := result.Fit; fit != nil && !fit.Fits() {
+		output += fmt.Sprintf("  ⚠ Message will be cut off by %.2fs (must start by %.2fs)\n",
+			fit.Overrun.Seconds(), fit.LatestDrop.Seconds())
+	}
 	if result.DeadAir > 0 {
 		output += fmt.Sprintf("  Dead air: %.2fs\n", result.DeadAir.Seconds())
 	}
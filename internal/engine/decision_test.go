@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"retape_ai/internal/config"
+	"retape_ai/internal/detector"
+)
+
+func TestTranscriptOutcomes(t *testing.T) {
+	tests := []struct {
+		text     string
+		outcome  string // "" if no decision
+		platform string
+	}{
+		{"Sorry, the mailbox is full and cannot accept any messages. Goodbye.", OutcomeUnavailable, ""},
+		{"The person you are trying to reach has not set up their voicemail.", OutcomeUnavailable, ""},
+		{"The Google subscriber you have called is not available.", "", "google_voice"},
+		{"Please stay on the line, your call is important to us.", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.EnableSTT = false
+			e := NewDecisionEngine(cfg, testSampleRate)
+			e.policy = &PriorityPolicy{}
+
+			e.processTranscript(detector.TranscriptEvent{Text: tt.text, Timestamp: 3 * time.Second, IsFinal: true})
+
+			switch {
+			case tt.outcome == "" && e.decisionResult != nil:
+				t.Fatalf("expected no decision, got %s (%s)", e.decisionResult.Outcome, e.decisionResult.Reason)
+			case tt.outcome != "" && (e.decisionResult == nil || e.decisionResult.Outcome != tt.outcome):
+				t.Fatalf("expected outcome %s, got %+v", tt.outcome, e.decisionResult)
+			case tt.outcome != "" && e.decisionResult.Drops():
+				t.Fatalf("expected %s not to drop", tt.outcome)
+			}
+			if got := e.platformName(); got != tt.platform {
+				t.Fatalf("expected platform %q, got %q", tt.platform, got)
+			}
+		})
+	}
+}
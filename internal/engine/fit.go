@@ -0,0 +1,62 @@
+package engine
+
+import "time"
+
+// MessageFit checks our prerecorded message against the mailbox's maximum
+// recording length at a given drop time
+type MessageFit struct {
+	RecordingStart time.Duration // when the mailbox's recording limit starts counting
+	LatestDrop     time.Duration // last drop time at which the whole message is recorded
+	Overrun        time.Duration // how much of the message the mailbox cuts off, 0 if it fits
+	Adjusted       bool          // the drop was moved earlier so the message fits
+}
+
+func (f *MessageFit) Fits() bool {
+	return f.Overrun == 0
+}
+
+// messageFit returns nil unless both the message duration and the mailbox
+// limit are configured. Without a beep the mailbox is assumed to start
+// recording when we start talking.
+func (e *DecisionEngine) messageFit(dropTime time.Duration) *MessageFit {
+	if e.config.MessageDuration == 0 || e.config.MaxRecordingLength == 0 {
+		return nil
+	}
+
+	start := dropTime
+	if e.config.RecordingIncludesGreeting {
+		start = 0
+	} else if e.beepDetected != nil {
+		start = e.beepDetected.EndTime
+	}
+
+	fit := &MessageFit{
+		RecordingStart: start,
+		LatestDrop:     start + e.config.MaxRecordingLength - e.config.MessageDuration,
+	}
+	if dropTime > fit.LatestDrop {
+		fit.Overrun = dropTime - fit.LatestDrop
+	}
+	return fit
+}
+
+// fitFallback moves a guessed drop time earlier when the message would
+// otherwise be cut off. Only a mailbox whose limit includes the greeting
+// gains anything from dropping earlier.
+func (e *DecisionEngine) fitFallback(decision *Decision) *MessageFit {
+	fit := e.messageFit(decision.DropTime)
+	if fit == nil || fit.Fits() || !e.config.RecordingIncludesGreeting {
+		return fit
+	}
+
+	latest := fit.LatestDrop
+	if latest < 0 {
+		latest = 0
+	}
+	decision.DropTime = latest
+	decision.Reason += " (moved earlier so the message fits the mailbox)"
+
+	fit = e.messageFit(latest)
+	fit.Adjusted = true
+	return fit
+}
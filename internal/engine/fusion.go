@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"retape_ai/internal/config"
+	"retape_ai/internal/detector"
+)
+
+func init() {
+	RegisterPolicy("fusion", func(*config.Config) (Policy, error) { return &FusionPolicy{}, nil })
+}
+
+// Evidence weights, in log-odds for "the greeting has ended". A beep adds
+// its calibrated log-odds, so only a near-certain beep decides alone; the
+// other weights are set by hand against the sample voicemails: ~2.8s of
+// silence after speech decides alone, and the smaller terms only tip
+// borderline cases.
+const (
+	fusionSilenceRate  = 2.5 // per second of silence after speech or a confirmed beep
+	fusionSilenceDelay = 0.4 // seconds of silence that are just a pause and count for nothing
+	fusionVoicePenalty = -3.0
+	fusionMusicStopped = 2.0
+	fusionPriorBeep    = -1.0 // no beep yet, but this mailbox beeped on the last call
+	fusionPriorNoBeep  = 1.0  // this mailbox didn't beep on the last call, silence is conclusive sooner
+	fusionNamePenalty  = -1.5 // the callee's name was said within nameWindow - the personal greeting is under way
+)
+
+var fusionPhraseWeights = map[string]float64{
+	detector.PhraseBeepCue:      1.0, // the end is near, but the beep itself is still to come
+	detector.PhraseLeaveMessage: 1.5,
+	detector.PhraseCallback:     1.5,
+	detector.PhraseOther:        0.5,
+}
+
+// FusionPolicy weighs all evidence together instead of ranking it: beep
+// confidence, silence duration, VAD state and phrase category each add to the
+// log-odds that the greeting has ended, and the message drops once the
+// resulting score reaches FusionThreshold. A weak beep then needs silence to
+// back it up, while phrase plus silence can drop without any beep.
+type FusionPolicy struct {
+	posterior float64
+}
+
+func (p *FusionPolicy) Name() string {
+	return "fusion"
+}
+
+// Posterior is the "greeting has ended" score at the last Decide
+func (p *FusionPolicy) Posterior() float64 {
+	return p.posterior
+}
+
+func (p *FusionPolicy) Decide(s *State, currentTime time.Duration) *Decision {
+	// Evidence about a beep is incomplete until its verification period is over
+	if s.BeepTracking || (s.Beep != nil && s.BeepConfirmedAt == 0) {
+		return nil
+	}
+
+	logOdds := logit(s.Config.FusionPrior)
+	var terms []string
+	add := func(name string, llr float64) {
+		logOdds += llr
+		terms = append(terms, fmt.Sprintf("%s %+.1f", name, llr))
+	}
+
+	var beepLLR, silenceLLR float64
+	if s.Beep != nil {
+		beepLLR = logit(s.Beep.Confidence)
+		add("beep", beepLLR)
+	}
+	// A beep-only mailbox has no speech for its silence to follow - the quiet
+	// after a confirmed beep counts instead
+	if s.InSilence && (s.HadSpeech || s.BeepConfirmedAt > 0) {
+		silence := s.SilenceDuration
+		if !s.HadSpeech {
+			silence = min(silence, currentTime-s.Beep.EndTime)
+		}
+		if seconds := silence.Seconds() - fusionSilenceDelay; seconds > 0 {
+			silenceLLR = fusionSilenceRate * seconds
+			add("silence", silenceLLR)
+		}
+	}
+	if s.VoiceActive {
+		add("voice", fusionVoicePenalty)
+	}
+	if s.Phrase != nil {
+		add(s.Phrase.Category, fusionPhraseWeights[s.Phrase.Category])
+	}
+	if s.MusicStoppedAt > 0 && s.LastSpeechTime <= s.MusicStoppedAt {
+		add("music stopped", fusionMusicStopped)
+	}
+	switch s.Context.PriorOutcome {
+	case PriorBeep:
+		if s.Beep == nil {
+			add("prior beep", fusionPriorBeep)
+		}
+	case PriorNoBeep:
+		add("prior no beep", fusionPriorNoBeep)
+	}
+	if s.Name != nil && currentTime-s.Name.Timestamp < nameWindow {
+		add("name", fusionNamePenalty)
+	}
+
+	p.posterior = 1 / (1 + math.Exp(-logOdds))
+	threshold := s.Config.FusionThreshold
+	if p.posterior < threshold {
+		return nil
+	}
+
+	// Never drop before a confirmed beep, however weak; otherwise drop into
+	// the silence
+	dropTime := currentTime
+	if s.Beep != nil {
+		dropTime = s.Beep.EndTime + 50*time.Millisecond
+	} else if silenceLLR > 0 {
+		dropTime = currentTime - s.SilenceDuration + 200*time.Millisecond
+	}
+
+	return &Decision{
+		DropTime:     dropTime,
+		DecisionTime: currentTime,
+		Reason: fmt.Sprintf("Posterior %.3f >= %.3f (%s) - dropping",
+			p.posterior, threshold, strings.Join(terms, ", ")),
+	}
+}
+
+// Final falls back to the priority ladder: at the end of the stream there is
+// nothing left to wait for, so ranking the evidence is enough
+func (p *FusionPolicy) Final(s *State, totalDuration time.Duration) Decision {
+	return (&PriorityPolicy{}).Final(s, totalDuration)
+}
+
+func logit(prob float64) float64 {
+	prob = math.Min(math.Max(prob, 1e-6), 1-1e-6)
+	return math.Log(prob / (1 - prob))
+}
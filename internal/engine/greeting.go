@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+// GreetingState is the phase of the call the engine believes it is in
+type GreetingState string
+
+const (
+	StatePreSpeech           GreetingState = "pre_speech"            // nothing said yet
+	StateGreeting            GreetingState = "greeting"              // greeting speech in progress
+	StatePause               GreetingState = "pause"                 // silence after speech, not yet confirmed
+	StatePostGreetingSilence GreetingState = "post_greeting_silence" // silence sustained long enough to end the greeting
+	StateBeepCandidate       GreetingState = "beep_candidate"        // beep heard, verifying no speech follows
+	StateBeepConfirmed       GreetingState = "beep_confirmed"        // beep verified
+	StateDecided             GreetingState = "decided"
+)
+
+// Transition is one state change, made at Timestamp in the stream
+type Transition struct {
+	From      GreetingState
+	To        GreetingState
+	Timestamp time.Duration
+	Cause     string
+}
+
+// greetingMachine is a trace of the call's phases for reporting. It follows
+// the detectors after each chunk; decisions are made by the policy from the
+// same detector state, never from the machine.
+type greetingMachine struct {
+	state        GreetingState
+	silenceStart time.Duration // start of the latest silence the detectors reported
+	transitions  []Transition
+}
+
+func newGreetingMachine() *greetingMachine {
+	return &greetingMachine{state: StatePreSpeech}
+}
+
+func (m *greetingMachine) to(state GreetingState, timestamp time.Duration, cause string) {
+	if state == m.state || m.state == StateDecided {
+		return
+	}
+	m.transitions = append(m.transitions, Transition{
+		From:      m.state,
+		To:        state,
+		Timestamp: timestamp,
+		Cause:     cause,
+	})
+	m.state = state
+}
+
+// reopen leaves the decided state when monitoring after a drop finds the
+// greeting wasn't over
+func (m *greetingMachine) reopen(state GreetingState, timestamp time.Duration, cause string) {
+	if m.state != StateDecided {
+		return
+	}
+	m.state = state
+	m.transitions = append(m.transitions, Transition{
+		From:      StateDecided,
+		To:        state,
+		Timestamp: timestamp,
+		Cause:     cause,
+	})
+}
+
+// updateState traces the state the detectors now describe. Beep states
+// follow the beep under verification; otherwise the state comes from the
+// silence detector. cause, if set, explains the transition better than the
+// default for the target state.
+func (e *DecisionEngine) updateState(currentTime time.Duration, cause string) {
+	// Kept current on every silent chunk, so a resumption is measured from the
+	// silence that just ended whichever state it interrupted
+	if silence := e.silenceDetector.GetSilenceDuration(currentTime); silence > 0 {
+		e.machine.silenceStart = currentTime - silence
+	}
+
+	var target GreetingState
+	switch {
+	case e.beepDetected != nil && e.beepConfirmedAt > 0:
+		target = StateBeepConfirmed
+	case e.beepDetected != nil:
+		target = StateBeepCandidate
+	case !e.silenceDetector.HadSpeech():
+		target = StatePreSpeech
+	case e.silenceDetector.IsConfirmedEnd():
+		target = StatePostGreetingSilence
+	case e.silenceDetector.GetSilenceDuration(currentTime) >= e.config.SilenceMinDur:
+		// Shorter gaps are between words, not pauses
+		target = StatePause
+	default:
+		target = StateGreeting
+	}
+
+	if cause == "" {
+		switch target {
+		case StateGreeting:
+			if e.machine.state == StatePreSpeech {
+				cause = "speech started"
+			} else if e.machine.silenceStart == 0 {
+				cause = "speech resumed"
+			} else {
+				cause = fmt.Sprintf("speech resumed after %v of silence", currentTime-e.machine.silenceStart)
+			}
+		case StatePause:
+			cause = fmt.Sprintf("silence since %.2fs", e.machine.silenceStart.Seconds())
+		case StatePostGreetingSilence:
+			cause = fmt.Sprintf("silence since %.2fs confirmed", e.silenceDetector.GetPotentialEndTime().Seconds())
+		case StateBeepConfirmed:
+			cause = "no speech after beep"
+		}
+	}
+	e.machine.to(target, currentTime, cause)
+}
+
+// State returns the engine's current state
+func (e *DecisionEngine) State() GreetingState {
+	return e.machine.state
+}
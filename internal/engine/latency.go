@@ -0,0 +1,164 @@
+package engine
+
+import (
+	"math"
+	"time"
+
+	"retape_ai/internal/detector"
+)
+
+// Confidence bands for the latency budget: evidence at or above
+// commitConfidence commits within the budget, evidence below
+// ambiguousConfidence gets a longer wait than the default.
+const (
+	commitConfidence    = 0.8
+	ambiguousConfidence = 0.5
+	maxStretch          = 1.5
+	minBeepVerify       = 300 * time.Millisecond // trailing silence takes this long to score
+	typicalPause        = 500 * time.Millisecond
+	greetingSpeech      = 2 * time.Second // voiced time of a complete short greeting
+	briefSpeech         = time.Second     // less than this is a "Hi," before a pause
+)
+
+// WaitPlan is one wait before committing to a drop, and the dead air versus
+// early-drop risk trade-off it makes
+type WaitPlan struct {
+	Name          string
+	Default       time.Duration // the configured wait
+	Wait          time.Duration // the wait actually used
+	Budget        time.Duration // LatencyBudget, 0 when waits are fixed
+	Confidence    float64       // strength of the evidence being waited on
+	EarlyDropRisk float64       // estimated chance the greeting continues after Wait
+}
+
+// ExpectedDeadAir is the silence the callee's mailbox records before our message
+func (p WaitPlan) ExpectedDeadAir() time.Duration {
+	return p.Wait
+}
+
+// Waits are the plans for every wait the engine and policies use
+type Waits struct {
+	BeepVerify    WaitPlan
+	PhraseSilence WaitPlan
+	Silence       WaitPlan
+	Confirm       WaitPlan // the learned silence window, used instead of Silence with AdaptiveSilence
+	ExpectsBeep   WaitPlan
+	MusicStop     WaitPlan
+}
+
+// waits plans each wait from the configured default and the current evidence.
+// Without a LatencyBudget every wait is its default.
+func (e *DecisionEngine) waits() Waits {
+	beepConfidence := 0.5
+	if e.beepDetected != nil {
+		beepConfidence = e.beepDetected.Confidence
+	}
+
+	phraseConfidence := 0.6
+	if phrase := e.phraseDetector.GetDetected(); phrase != nil &&
+		(phrase.Category == detector.PhraseLeaveMessage || phrase.Category == detector.PhraseCallback) {
+		phraseConfidence = commitConfidence
+	}
+
+	silence := e.config.BeepWaitTimeout
+	confirm := e.silenceDetector.ConfirmWindow()
+	// An announced beep is still to come, so silence alone says less
+	expectsBeep := e.silenceConfidence(e.config.ExpectsBeepWait) - 0.2
+
+	return Waits{
+		BeepVerify:    e.planWait("beep_verify", PostBeepVerifyDuration, beepConfidence, minBeepVerify),
+		PhraseSilence: e.planWait("phrase_silence", e.config.PhraseSilenceWait, phraseConfidence, 0),
+		Silence:       e.planWait("silence", silence, e.silenceConfidence(silence), 0),
+		Confirm:       e.planWait("confirm_window", confirm, e.silenceConfidence(confirm), 0),
+		ExpectsBeep:   e.planWait("expects_beep", e.config.ExpectsBeepWait, expectsBeep, 0),
+		MusicStop:     e.planWait("music_stop", e.config.MusicStopWait, 0.6, 0),
+	}
+}
+
+// silenceConfidence is how conclusive a silence of length wait would be as
+// the end of the greeting, from the evidence so far: how it compares with
+// the speaker's pauses, how much speech the VAD has heard, and what the
+// greeting said
+func (e *DecisionEngine) silenceConfidence(wait time.Duration) float64 {
+	confidence := ambiguousConfidence
+
+	if longest := e.longestPause(); longest > 0 {
+		switch {
+		case 4*longest < wait:
+			confidence += 0.2
+		case 2*longest < wait:
+			confidence += 0.1
+		}
+	}
+
+	switch {
+	case e.voiceOutsideMusic >= greetingSpeech:
+		confidence += 0.1
+	case e.voiceOutsideMusic < briefSpeech:
+		confidence -= 0.1
+	}
+
+	if phrase := e.phraseDetector.GetDetected(); phrase != nil {
+		switch phrase.Category {
+		case detector.PhraseLeaveMessage, detector.PhraseCallback:
+			confidence += 0.2
+		case detector.PhraseBeepCue:
+			confidence -= 0.1
+		default:
+			confidence += 0.05
+		}
+	}
+	return math.Min(math.Max(confidence, 0), 1)
+}
+
+// planWait fits a wait to the latency budget: confident evidence commits
+// within the budget, moderate evidence interpolates between budget and
+// default, and ambiguous evidence waits longer than the default.
+func (e *DecisionEngine) planWait(name string, def time.Duration, confidence float64, floor time.Duration) WaitPlan {
+	budget := e.config.LatencyBudget
+	wait := def
+
+	if budget > 0 {
+		switch {
+		case confidence >= commitConfidence:
+			wait = min(def, budget)
+		case confidence >= ambiguousConfidence:
+			if budget < def {
+				share := (commitConfidence - confidence) / (commitConfidence - ambiguousConfidence)
+				wait = budget + time.Duration(share*float64(def-budget))
+			}
+		default:
+			stretch := 1 + (ambiguousConfidence - confidence)
+			wait = time.Duration(math.Min(stretch, maxStretch) * float64(def))
+		}
+		wait = max(wait, floor)
+	}
+
+	return WaitPlan{
+		Name:          name,
+		Default:       def,
+		Wait:          wait,
+		Budget:        budget,
+		Confidence:    confidence,
+		EarlyDropRisk: e.earlyDropRisk(wait, confidence),
+	}
+}
+
+// earlyDropRisk models pauses as exponentially distributed around the
+// speaker's longest observed pause: the chance the greeting continues after
+// wait, discounted by how conclusive the evidence already is
+func (e *DecisionEngine) earlyDropRisk(wait time.Duration, confidence float64) float64 {
+	scale := e.longestPause()
+	if scale == 0 {
+		scale = typicalPause
+	}
+	return (1 - confidence) * math.Exp(-wait.Seconds()/scale.Seconds())
+}
+
+func (e *DecisionEngine) longestPause() time.Duration {
+	var longest time.Duration
+	for _, p := range e.silenceDetector.Pauses() {
+		longest = max(longest, p)
+	}
+	return longest
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+// RestartMinSpeech is how much renewed speech after a drop means the
+// greeting wasn't over, rather than a cough or a click
+const RestartMinSpeech = 300 * time.Millisecond
+
+// monitor keeps analyzing for PostDropMonitor after a drop decision. A beep
+// or renewed greeting speech in that window restarts the decision. It returns
+// false once there is nothing left to watch for.
+func (e *DecisionEngine) monitor(currentTime, chunkDuration time.Duration) bool {
+	if e.config.PostDropMonitor == 0 || e.monitorDone || !e.decisionResult.Drops() {
+		return false
+	}
+	if currentTime-e.decisionResult.DecisionMadeAt > e.config.PostDropMonitor {
+		return false
+	}
+
+	lastDrop := e.drops[len(e.drops)-1]
+	if e.beepDetected != nil && e.beepDetected != e.droppedBeep && e.beepDetected.EndTime > lastDrop {
+		e.restart(currentTime, StateBeepCandidate,
+			fmt.Sprintf("late beep at %.2fs", e.beepDetected.EndTime.Seconds()))
+		return true
+	}
+
+	// After a beep the mailbox is recording and any speech is most likely our
+	// own message bleeding back - only silence-based drops restart on speech
+	if e.droppedBeep != nil {
+		return true
+	}
+
+	if e.silenceDetector.VoiceActive() {
+		e.monitorSpeech += chunkDuration
+		if e.monitorSpeech >= RestartMinSpeech {
+			e.restart(currentTime, StateGreeting, "greeting speech resumed")
+		}
+	} else {
+		e.monitorSpeech = 0
+	}
+	return true
+}
+
+// restart reopens the decision after a drop turned out to be premature. What
+// was learned about the greeting (phrases, platform) stays. A late beep goes
+// through the usual verification; resumed speech means the silence that ended
+// the greeting no longer counts.
+func (e *DecisionEngine) restart(currentTime time.Duration, state GreetingState, cause string) {
+	lastDrop := e.drops[len(e.drops)-1]
+	e.signals = append(e.signals, Signal{
+		Type:      "restart",
+		Timestamp: currentTime,
+		Details:   fmt.Sprintf("%s after drop at %.2fs - deciding again", cause, lastDrop.Seconds()),
+	})
+	e.machine.reopen(state, currentTime, cause)
+	e.monitorSpeech = 0
+
+	if state == StateGreeting {
+		e.firstSilenceAt = 0
+		e.silenceDetector.Rearm()
+		e.beepDetected = nil
+		e.beepConfirmedAt = 0
+	}
+}
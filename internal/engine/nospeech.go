@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"retape_ai/internal/detector"
+)
+
+// noSpeech classifies a stream in which nothing has been heard that could be
+// a greeting: no speech, no end phrase and no beep. While streaming it waits
+// for NoSpeechTimeout; at the end of the stream (final) it classifies
+// whatever there is. It returns true when it made the decision.
+//
+// Beep-only mailboxes never get here - the beep decides as usual and
+// makeDecision reports OutcomeBeepOnly.
+func (e *DecisionEngine) noSpeech(currentTime time.Duration, final bool) bool {
+	if e.phraseFound || e.beepDetected != nil || e.beepDetector.IsTracking() {
+		return false
+	}
+
+	if e.musicDetector.IsSounding() {
+		// An all-music greeting ends when the music stops - only music that
+		// outlasts the stream is taken as hold
+		if !final || e.heardGreeting() {
+			return false
+		}
+		e.makeNoDropDecision(
+			OutcomeCarrierHold,
+			fmt.Sprintf("Music for %.1fs with no greeting - carrier hold, not dropping", currentTime.Seconds()),
+			currentTime,
+		)
+		return true
+	}
+
+	// Rings mean the call has not been answered yet: the wait for a greeting
+	// starts after the last one, and a stream that ends ringing never connected
+	if lastRing := e.toneClassifier.RingingUntil(); lastRing > 0 && !e.silenceDetector.HadSpeech() {
+		if final {
+			e.makeNoDropDecision(
+				OutcomeNetworkTone,
+				fmt.Sprintf("Ringing until %.1fs and no answer - call did not reach voicemail, not dropping", lastRing.Seconds()),
+				currentTime,
+			)
+			e.decisionResult.NetworkTone = detector.ToneRingback
+			return true
+		}
+		if currentTime-lastRing < e.config.NoSpeechTimeout {
+			return false
+		}
+	}
+
+	if e.silenceDetector.HadSpeech() || (!final && currentTime < e.config.NoSpeechTimeout) {
+		return false
+	}
+
+	peak := e.silenceDetector.PeakLevel()
+	switch {
+	case peak < e.config.DeadAirLevel:
+		e.makeNoDropDecision(
+			OutcomeDeadAir,
+			fmt.Sprintf("No sound for %.1fs (peak level %.4f) - dead line, not dropping", currentTime.Seconds(), peak),
+			currentTime,
+		)
+	default:
+		// The line is live, so a mailbox with a blank greeting is most likely
+		// already recording
+		e.makeDecision(currentTime,
+			fmt.Sprintf("No speech for %.1fs on a live line (peak level %.4f) - silent greeting, dropping now",
+				currentTime.Seconds(), peak),
+			currentTime)
+		e.decisionResult.Outcome = OutcomeSilentGreeting
+		e.decisionResult.DeadAir = currentTime
+	}
+	return true
+}
+
+// heardGreeting reports whether anything but music has been heard. The VAD
+// takes music for voice, so only voice heard outside the music counts;
+// music is detected a MusicWindow after it starts.
+func (e *DecisionEngine) heardGreeting() bool {
+	return e.phraseFound || e.voiceOutsideMusic > e.config.MusicWindow
+}
@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"retape_ai/internal/config"
+	"retape_ai/internal/detector"
+)
+
+// State is the signal state accumulated so far, as seen by a Policy
+type State struct {
+	Config  *config.Config
+	Signals []Signal
+	Phase   GreetingState
+
+	Beep            *detector.BeepEvent // latest beep not yet rejected
+	BeepConfirmedAt time.Duration       // 0 while the beep is awaiting verification
+	BeepTracking    bool                // a beep-like tone is sounding right now
+
+	PhraseFound bool
+	ExpectsBeep bool // the greeting announced a beep/tone
+	Phrase      *detector.PhraseEvent
+
+	FirstSilenceAt  time.Duration // start of the first confirmed silence
+	InSilence       bool
+	SilenceDuration time.Duration // length of the current silence so far
+	VoiceActive     bool          // the VAD hears speech right now
+	HadSpeech       bool
+	LastSpeechTime  time.Duration
+	ConfirmWindow   time.Duration // sustained silence currently required to confirm
+	MusicStoppedAt  time.Duration
+
+	Platform string
+	Context  CallContext           // the dialer's hints for this call
+	Name     *detector.PhraseEvent // latest mention of the expected name
+
+	Beeps []SequencedBeep // every beep so far, with its role in the sequence
+	Waits Waits           // how long to wait for each kind of evidence, per the latency budget
+}
+
+// Decision is a policy's verdict: drop the message at DropTime, decided at
+// DecisionTime
+type Decision struct {
+	DropTime     time.Duration
+	DecisionTime time.Duration
+	Reason       string
+	Plan         *WaitPlan // the wait that was served, if any
+}
+
+// Policy turns accumulated signals into a drop decision. Decide is called
+// after every chunk and returns nil to keep listening; Final is called when
+// the stream ends without a decision.
+type Policy interface {
+	Name() string
+	Decide(state *State, now time.Duration) *Decision
+	Final(state *State, total time.Duration) Decision
+}
+
+// PolicyFactory builds a policy for one call. It may fail, e.g. when the
+// policy's own configuration is invalid.
+type PolicyFactory func(cfg *config.Config) (Policy, error)
+
+var policies = map[string]PolicyFactory{}
+
+// RegisterPolicy makes a policy selectable by name
+func RegisterPolicy(name string, factory PolicyFactory) {
+	policies[name] = factory
+}
+
+// NewPolicy returns a fresh instance of the named policy
+func NewPolicy(name string, cfg *config.Config) (Policy, error) {
+	factory, ok := policies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown decision policy %q (available: %s)", name, strings.Join(PolicyNames(), ", "))
+	}
+	return factory(cfg)
+}
+
+// PolicyNames lists the registered policies
+func PolicyNames() []string {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
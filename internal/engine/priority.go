@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"retape_ai/internal/config"
+)
+
+func init() {
+	RegisterPolicy("priority", func(*config.Config) (Policy, error) { return &PriorityPolicy{}, nil })
+}
+
+// PriorityPolicy is the default policy: a fixed ladder where a verified beep
+// beats an end phrase, which beats plain silence
+type PriorityPolicy struct{}
+
+func (p *PriorityPolicy) Name() string {
+	return "priority"
+}
+
+func (p *PriorityPolicy) Decide(s *State, currentTime time.Duration) *Decision {
+	// Priority 1: Beep detected AND confirmed (verify period passed)
+	if s.Beep != nil && s.BeepConfirmedAt > 0 {
+		plan := s.Waits.BeepVerify
+		return &Decision{
+			DropTime:     s.Beep.EndTime + 50*time.Millisecond,
+			DecisionTime: currentTime,
+			Reason: fmt.Sprintf("Beep detected and confirmed (no speech resumed, confidence %.2f) - dropping after beep",
+				s.Beep.Confidence),
+			Plan: &plan,
+		}
+	}
+
+	// The VAD counts tones as non-speech, so silence can run through a beep.
+	// A beep that is still sounding or awaiting verification outranks silence.
+	if s.BeepTracking || (s.Beep != nil && s.BeepConfirmedAt == 0) {
+		return nil
+	}
+
+	// The callee's name was just said - the personal greeting is under way,
+	// whatever silence follows is a pause in it
+	if s.Name != nil && currentTime-s.Name.Timestamp < nameWindow {
+		return nil
+	}
+
+	// The greeting announced a beep, or this number's mailbox beeped last time
+	priorBeep := s.Context.PriorOutcome == PriorBeep
+	expectsBeep := s.ExpectsBeep || priorBeep
+
+	// Priority 2: End phrase detected + confirmed silence = drop quickly
+	if s.PhraseFound && s.FirstSilenceAt > 0 && !expectsBeep {
+		// Phrase found, silence confirmed, no beep expected - drop after a short wait
+		plan := s.Waits.PhraseSilence
+		wait := plan.Wait
+		timeSinceSilence := currentTime - s.FirstSilenceAt
+		if timeSinceSilence >= wait {
+			return &Decision{
+				DropTime:     s.FirstSilenceAt + 200*time.Millisecond,
+				DecisionTime: s.FirstSilenceAt + wait,
+				Reason:       "End phrase + silence detected (no beep expected) - dropping",
+				Plan:         &plan,
+			}
+		}
+	}
+
+	// Priority 3: Phrase indicates beep is coming - wait longer for beep
+	if expectsBeep && s.FirstSilenceAt > 0 {
+		plan := s.Waits.ExpectsBeep
+		wait := plan.Wait
+		timeSinceSilence := currentTime - s.FirstSilenceAt
+		if timeSinceSilence >= wait {
+			reason := fmt.Sprintf("Phrase indicated beep expected, waited %.1fs - dropping", wait.Seconds())
+			if !s.ExpectsBeep {
+				reason = fmt.Sprintf("Previous call ended in a beep, waited %.1fs in silence - dropping", wait.Seconds())
+			}
+			return &Decision{
+				DropTime:     s.FirstSilenceAt + 200*time.Millisecond,
+				DecisionTime: s.FirstSilenceAt + wait,
+				Reason:       reason,
+				Plan:         &plan,
+			}
+		}
+	}
+
+	// Music bed/all-music greeting stopped and nobody spoke since - end of greeting
+	if s.MusicStoppedAt > 0 && !expectsBeep && s.LastSpeechTime <= s.MusicStoppedAt {
+		plan := s.Waits.MusicStop
+		wait := plan.Wait
+		if currentTime-s.MusicStoppedAt >= wait {
+			return &Decision{
+				DropTime:     s.MusicStoppedAt + 200*time.Millisecond,
+				DecisionTime: s.MusicStoppedAt + wait,
+				Reason:       fmt.Sprintf("Music stopped, no speech for %.1fs - dropping", wait.Seconds()),
+				Plan:         &plan,
+			}
+		}
+	}
+
+	// Priority 4: Confirmed silence + timeout expired (no phrase indicating beep)
+	// Skip this if we expect a beep - let Priority 3 handle the longer wait
+	if s.FirstSilenceAt > 0 && s.HadSpeech && !expectsBeep {
+		plan := s.Waits.Silence
+		reason := "Confirmed silence, waited %.1fs for beep - dropping"
+		if s.Context.PriorOutcome == PriorNoBeep {
+			// This mailbox didn't beep last time - no point waiting for one
+			plan = s.Waits.PhraseSilence
+			reason = "Confirmed silence, previous call had no beep, waited %.1fs - dropping"
+		} else if s.Config.AdaptiveSilence {
+			// The learned window already reflects how long this speaker pauses
+			plan = s.Waits.Confirm
+		}
+		wait := plan.Wait
+		timeSinceSilence := currentTime - s.FirstSilenceAt
+		if timeSinceSilence >= wait {
+			return &Decision{
+				DropTime:     s.FirstSilenceAt + 200*time.Millisecond,
+				DecisionTime: s.FirstSilenceAt + wait,
+				Reason:       fmt.Sprintf(reason, wait.Seconds()),
+				Plan:         &plan,
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *PriorityPolicy) Final(s *State, totalDuration time.Duration) Decision {
+	d := Decision{DecisionTime: totalDuration}
+
+	if s.Beep != nil {
+		d.DropTime = s.Beep.EndTime + 50*time.Millisecond
+		d.Reason = "Beep detected at end - dropping after beep"
+	} else if s.FirstSilenceAt > 0 && s.HadSpeech {
+		d.DropTime = s.FirstSilenceAt + 200*time.Millisecond
+		d.Reason = "Silence after speech - no beep detected"
+	} else if s.PhraseFound {
+		if s.Phrase != nil {
+			d.DropTime = s.Phrase.Timestamp + 1*time.Second
+			d.Reason = "End phrase detected"
+		}
+	} else {
+		d.DropTime = time.Duration(float64(totalDuration) * 0.9)
+		d.Reason = "No clear signal - using fallback (90% of duration)"
+	}
+
+	return d
+}
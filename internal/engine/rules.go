@@ -0,0 +1,485 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"retape_ai/internal/config"
+	"retape_ai/internal/detector"
+)
+
+func init() {
+	RegisterPolicy("rules", func(cfg *config.Config) (Policy, error) {
+		if cfg.RulesFile == "" {
+			return nil, fmt.Errorf("rules policy needs a rules file (RulesFile / -rules)")
+		}
+		return LoadRules(cfg.RulesFile, cfg)
+	})
+}
+
+// RulePolicy evaluates decision rules read from a file, one per line:
+//
+//	when <condition> then drop at <anchor>[+offset] [reason "<text>"]
+//	when <condition> then wait
+//
+// A condition is terms joined by "and", each optionally prefixed with "not":
+// a flag (expectsBeep, beepConfirmed, signal:dtmf, ...) or a comparison of a
+// metric with a duration, a config wait or a number (silence >= 3s,
+// silence >= ExpectsBeepWait, beepConfidence < 0.6). Rules are tried in order
+// and the first whose condition holds decides; "wait" keeps listening without
+// trying later rules. Blank lines and lines starting with # are ignored.
+//
+// Everything is validated when the file is loaded, including that each drop
+// anchor is guaranteed to exist by its rule's condition.
+type RulePolicy struct {
+	source string
+	rules  []rule
+}
+
+type rule struct {
+	line   int
+	text   string
+	terms  []ruleTerm
+	wait   bool
+	anchor string
+	offset time.Duration
+	reason string
+}
+
+type ruleTerm struct {
+	negate bool
+	flag   string // flag name, or "" for a comparison
+	metric string
+	op     string
+	value  func(s *State) float64
+}
+
+// Flags usable as condition terms. "signal:<type>", "phrase:<category>" and
+// "platform:<name>" are handled separately.
+var ruleFlags = map[string]func(s *State) bool{
+	"beep":             func(s *State) bool { return s.Beep != nil },
+	"beepConfirmed":    func(s *State) bool { return s.Beep != nil && s.BeepConfirmedAt > 0 },
+	"beepPending":      func(s *State) bool { return s.Beep != nil && s.BeepConfirmedAt == 0 },
+	"beepTracking":     func(s *State) bool { return s.BeepTracking },
+	"phrase":           func(s *State) bool { return s.PhraseFound },
+	"expectsBeep":      func(s *State) bool { return s.ExpectsBeep },
+	"hadSpeech":        func(s *State) bool { return s.HadSpeech },
+	"inSilence":        func(s *State) bool { return s.InSilence },
+	"silenceConfirmed": func(s *State) bool { return s.FirstSilenceAt > 0 },
+	"voice":            func(s *State) bool { return s.VoiceActive },
+	"adaptiveSilence":  func(s *State) bool { return s.Config.AdaptiveSilence },
+	"priorBeep":        func(s *State) bool { return s.Context.PriorOutcome == PriorBeep },
+	"priorNoBeep":      func(s *State) bool { return s.Context.PriorOutcome == PriorNoBeep },
+	"name":             func(s *State) bool { return s.Name != nil },
+	// Music stopped and nobody has spoken since
+	"musicStopped": func(s *State) bool { return s.MusicStoppedAt > 0 && s.LastSpeechTime <= s.MusicStoppedAt },
+}
+
+// Metrics usable in comparisons. ok is false when the metric's reference
+// point hasn't happened yet; comparisons on it are then false.
+var ruleMetrics = map[string]func(s *State, now time.Duration) (value float64, ok bool){
+	"silence": func(s *State, now time.Duration) (float64, bool) {
+		return (now - s.FirstSilenceAt).Seconds(), s.FirstSilenceAt > 0
+	},
+	"currentSilence": func(s *State, now time.Duration) (float64, bool) {
+		return s.SilenceDuration.Seconds(), s.InSilence
+	},
+	"sinceBeep": func(s *State, now time.Duration) (float64, bool) {
+		if s.Beep == nil {
+			return 0, false
+		}
+		return (now - s.Beep.EndTime).Seconds(), true
+	},
+	"sincePhrase": func(s *State, now time.Duration) (float64, bool) {
+		if s.Phrase == nil {
+			return 0, false
+		}
+		return (now - s.Phrase.Timestamp).Seconds(), true
+	},
+	"sinceMusicStop": func(s *State, now time.Duration) (float64, bool) {
+		return (now - s.MusicStoppedAt).Seconds(), s.MusicStoppedAt > 0
+	},
+	"sinceName": func(s *State, now time.Duration) (float64, bool) {
+		if s.Name == nil {
+			return 0, false
+		}
+		return (now - s.Name.Timestamp).Seconds(), true
+	},
+	"elapsed": func(s *State, now time.Duration) (float64, bool) {
+		return now.Seconds(), true
+	},
+	"beepConfidence": func(s *State, now time.Duration) (float64, bool) {
+		if s.Beep == nil {
+			return 0, false
+		}
+		return s.Beep.Confidence, true
+	},
+}
+
+// Metrics compared against plain numbers rather than durations
+var ruleNumericMetrics = map[string]bool{"beepConfidence": true}
+
+// Config waits usable as comparison values, so platform timing overrides and
+// the latency budget still apply to rules
+var ruleWaits = map[string]func(s *State) time.Duration{
+	"BeepWaitTimeout":   func(s *State) time.Duration { return s.Waits.Silence.Wait },
+	"PhraseSilenceWait": func(s *State) time.Duration { return s.Waits.PhraseSilence.Wait },
+	"ExpectsBeepWait":   func(s *State) time.Duration { return s.Waits.ExpectsBeep.Wait },
+	"MusicStopWait":     func(s *State) time.Duration { return s.Waits.MusicStop.Wait },
+	"ConfirmWindow":     func(s *State) time.Duration { return s.Waits.Confirm.Wait },
+}
+
+// Drop anchors, and the condition terms that guarantee each one exists
+var ruleAnchors = map[string]struct {
+	at     func(s *State, now time.Duration) time.Duration
+	guards []string
+}{
+	"now": {func(s *State, now time.Duration) time.Duration { return now }, nil},
+	"beep": {func(s *State, now time.Duration) time.Duration { return s.Beep.EndTime },
+		[]string{"beep", "beepConfirmed", "beepPending", "sinceBeep", "beepConfidence"}},
+	"silence": {func(s *State, now time.Duration) time.Duration { return s.FirstSilenceAt },
+		[]string{"silenceConfirmed", "silence"}},
+	"currentSilence": {func(s *State, now time.Duration) time.Duration { return now - s.SilenceDuration },
+		[]string{"inSilence", "currentSilence"}},
+	"phrase": {func(s *State, now time.Duration) time.Duration { return s.Phrase.Timestamp },
+		[]string{"sincePhrase"}},
+	"musicStop": {func(s *State, now time.Duration) time.Duration { return s.MusicStoppedAt },
+		[]string{"musicStopped", "sinceMusicStop"}},
+}
+
+var ruleSignalTypes = []string{"beep", "beep_candidate", "silence", "phrase", "dtmf", "network_tone",
+	"fax_modem", "music", "clipping", "echo", "hum", "impairment", "platform", "restart"}
+
+// rulePhraseCategories are the end phrase categories State.Phrase can carry.
+// The callee's name has its own flag.
+var rulePhraseCategories = []string{detector.PhraseBeepCue, detector.PhraseLeaveMessage,
+	detector.PhraseCallback, detector.PhraseOther}
+
+// LoadRules reads and validates a rules file against cfg's platforms
+func LoadRules(path string, cfg *config.Config) (*RulePolicy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rules: %w", err)
+	}
+	defer f.Close()
+	return ParseRules(f, path, cfg)
+}
+
+// ParseRules reads and validates rules; source names them in errors and cfg
+// supplies the platform names "platform:<name>" may use
+func ParseRules(r io.Reader, source string, cfg *config.Config) (*RulePolicy, error) {
+	p := &RulePolicy{source: source}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		parsed, err := parseRule(text, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", source, line, err)
+		}
+		parsed.line = line
+		p.rules = append(p.rules, parsed)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	if len(p.rules) == 0 {
+		return nil, fmt.Errorf("%s: no rules", source)
+	}
+	return p, nil
+}
+
+func parseRule(text string, cfg *config.Config) (rule, error) {
+	r := rule{text: text}
+
+	body := text
+	if i := strings.Index(body, ` reason "`); i >= 0 {
+		reason, err := strconv.Unquote(strings.TrimSpace(body[i+len(" reason "):]))
+		if err != nil {
+			return r, fmt.Errorf("malformed reason: %w", err)
+		}
+		r.reason = reason
+		body = body[:i]
+	}
+
+	fields := strings.Fields(body)
+	then := -1
+	for i, f := range fields {
+		if f == "then" {
+			then = i
+			break
+		}
+	}
+	if len(fields) == 0 || fields[0] != "when" || then < 0 {
+		return r, fmt.Errorf("expected 'when <condition> then <action>'")
+	}
+
+	terms, err := parseCondition(fields[1:then], cfg)
+	if err != nil {
+		return r, err
+	}
+	r.terms = terms
+
+	action := fields[then+1:]
+	switch {
+	case len(action) == 1 && action[0] == "wait":
+		r.wait = true
+		if r.reason != "" {
+			return r, fmt.Errorf("'wait' takes no reason")
+		}
+		return r, nil
+	case len(action) == 3 && action[0] == "drop" && action[1] == "at":
+		if err := r.parseAnchor(action[2]); err != nil {
+			return r, err
+		}
+	default:
+		return r, fmt.Errorf("expected action 'wait' or 'drop at <anchor>[+offset]'")
+	}
+
+	if r.reason == "" {
+		r.reason = "Rule: " + body
+	}
+	return r, nil
+}
+
+func parseCondition(tokens []string, cfg *config.Config) ([]ruleTerm, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+
+	var terms []ruleTerm
+	for len(tokens) > 0 {
+		var term ruleTerm
+		if tokens[0] == "not" {
+			term.negate = true
+			tokens = tokens[1:]
+		}
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("'not' without a term")
+		}
+
+		// A term runs up to the next "and"
+		end := len(tokens)
+		for i, t := range tokens {
+			if t == "and" {
+				end = i
+				break
+			}
+		}
+		switch end {
+		case 1:
+			if err := validateFlag(tokens[0], cfg); err != nil {
+				return nil, err
+			}
+			term.flag = tokens[0]
+		case 3:
+			if err := term.parseComparison(tokens[0], tokens[1], tokens[2]); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("expected a flag or '<metric> <op> <value>', got %q", strings.Join(tokens[:end], " "))
+		}
+		terms = append(terms, term)
+
+		tokens = tokens[end:]
+		if len(tokens) > 0 {
+			tokens = tokens[1:] // "and"
+			if len(tokens) == 0 {
+				return nil, fmt.Errorf("condition ends with 'and'")
+			}
+		}
+	}
+	return terms, nil
+}
+
+func validateFlag(flag string, cfg *config.Config) error {
+	if _, ok := ruleFlags[flag]; ok {
+		return nil
+	}
+	kind, value, found := strings.Cut(flag, ":")
+	if found && value != "" {
+		switch kind {
+		case "signal":
+			for _, t := range ruleSignalTypes {
+				if t == value {
+					return nil
+				}
+			}
+			return fmt.Errorf("unknown signal type %q", value)
+		case "phrase":
+			for _, c := range rulePhraseCategories {
+				if c == value {
+					return nil
+				}
+			}
+			return fmt.Errorf("unknown phrase category %q", value)
+		case "platform":
+			for _, fp := range cfg.Platforms {
+				if fp.Name == value {
+					return nil
+				}
+			}
+			return fmt.Errorf("unknown platform %q", value)
+		}
+	}
+	return fmt.Errorf("unknown flag %q", flag)
+}
+
+func (t *ruleTerm) parseComparison(metric, op, value string) error {
+	if _, ok := ruleMetrics[metric]; !ok {
+		return fmt.Errorf("unknown metric %q", metric)
+	}
+	switch op {
+	case "<", "<=", ">", ">=", "==", "!=":
+	default:
+		return fmt.Errorf("unknown operator %q", op)
+	}
+	t.metric, t.op = metric, op
+
+	if ruleNumericMetrics[metric] {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s compares against a number, got %q", metric, value)
+		}
+		t.value = func(*State) float64 { return n }
+		return nil
+	}
+
+	if wait, ok := ruleWaits[value]; ok {
+		t.value = func(s *State) float64 { return wait(s).Seconds() }
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("%s compares against a duration or config wait, got %q", metric, value)
+	}
+	t.value = func(*State) float64 { return d.Seconds() }
+	return nil
+}
+
+func (r *rule) parseAnchor(expr string) error {
+	name, offset := expr, ""
+	if i := strings.IndexAny(expr, "+-"); i >= 0 {
+		name, offset = expr[:i], expr[i:]
+	}
+
+	anchor, ok := ruleAnchors[name]
+	if !ok {
+		return fmt.Errorf("unknown anchor %q", name)
+	}
+	if offset != "" {
+		d, err := time.ParseDuration(offset)
+		if err != nil {
+			return fmt.Errorf("malformed offset %q", offset)
+		}
+		r.offset = d
+	}
+	r.anchor = name
+
+	if anchor.guards == nil {
+		return nil
+	}
+	for _, term := range r.terms {
+		if term.negate {
+			continue
+		}
+		for _, guard := range anchor.guards {
+			if term.flag == guard || term.metric == guard || (name == "phrase" && strings.HasPrefix(term.flag, "phrase")) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("anchor %q may not exist: the condition must require one of %s", name, strings.Join(anchor.guards, ", "))
+}
+
+func (p *RulePolicy) Name() string {
+	return "rules"
+}
+
+func (p *RulePolicy) Decide(s *State, currentTime time.Duration) *Decision {
+	for _, r := range p.rules {
+		if !r.matches(s, currentTime) {
+			continue
+		}
+		if r.wait {
+			return nil
+		}
+		return &Decision{
+			DropTime:     ruleAnchors[r.anchor].at(s, currentTime) + r.offset,
+			DecisionTime: currentTime,
+			Reason:       r.reason,
+		}
+	}
+	return nil
+}
+
+// Final falls back to the priority ladder; rules only cover the live decision
+func (p *RulePolicy) Final(s *State, totalDuration time.Duration) Decision {
+	return (&PriorityPolicy{}).Final(s, totalDuration)
+}
+
+func (r *rule) matches(s *State, now time.Duration) bool {
+	for _, t := range r.terms {
+		if t.holds(s, now) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *ruleTerm) holds(s *State, now time.Duration) bool {
+	if t.flag != "" {
+		return flagHolds(t.flag, s)
+	}
+
+	value, ok := ruleMetrics[t.metric](s, now)
+	if !ok {
+		return false
+	}
+	target := t.value(s)
+	switch t.op {
+	case "<":
+		return value < target
+	case "<=":
+		return value <= target
+	case ">":
+		return value > target
+	case ">=":
+		return value >= target
+	case "==":
+		return value == target
+	default:
+		return value != target
+	}
+}
+
+func flagHolds(flag string, s *State) bool {
+	if f, ok := ruleFlags[flag]; ok {
+		return f(s)
+	}
+
+	kind, value, _ := strings.Cut(flag, ":")
+	switch kind {
+	case "signal":
+		for _, sig := range s.Signals {
+			if sig.Type == value {
+				return true
+			}
+		}
+	case "phrase":
+		return s.Phrase != nil && s.Phrase.Category == value
+	case "platform":
+		return s.Platform == value
+	}
+	return false
+}
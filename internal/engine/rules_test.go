@@ -0,0 +1,296 @@
+package engine
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"retape_ai/internal/config"
+	"retape_ai/internal/detector"
+)
+
+func TestParseRulesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		err   string
+	}{
+		{"empty file", "# only a comment\n\n", "no rules"},
+		{"no when", "if beep then wait", "expected 'when <condition> then <action>'"},
+		{"no then", "when beep drop at beep", "expected 'when <condition> then <action>'"},
+		{"empty condition", "when then wait", "empty condition"},
+		{"dangling not", "when beep and not then wait", "'not' without a term"},
+		{"dangling and", "when beep and then wait", "condition ends with 'and'"},
+		{"two-word term", "when silence 3s then wait", "expected a flag or '<metric> <op> <value>'"},
+		{"unknown flag", "when beeped then wait", `unknown flag "beeped"`},
+		{"empty signal", "when signal: then wait", `unknown flag "signal:"`},
+		{"unknown signal", "when signal:fax then wait", `unknown signal type "fax"`},
+		{"empty phrase", "when phrase: then wait", `unknown flag "phrase:"`},
+		{"unknown phrase", "when phrase:beep_promt then wait", `unknown phrase category "beep_promt"`},
+		{"name as phrase", "when phrase:personal then wait", `unknown phrase category "personal"`},
+		{"unknown platform", "when platform:googel_voice then wait", `unknown platform "googel_voice"`},
+		{"unknown metric", "when sinceSilence > 1s then wait", `unknown metric "sinceSilence"`},
+		{"unknown operator", "when silence => 1s then wait", `unknown operator "=>"`},
+		{"duration metric with number", "when silence > 3 then wait", "compares against a duration or config wait"},
+		{"unknown config wait", "when silence > BeepWait then wait", "compares against a duration or config wait"},
+		{"numeric metric with duration", "when beepConfidence > 1s then wait", "compares against a number"},
+		{"unknown action", "when beep then hang up", "expected action 'wait' or 'drop at <anchor>[+offset]'"},
+		{"drop without anchor", "when beep then drop at", "expected action 'wait' or 'drop at <anchor>[+offset]'"},
+		{"unknown anchor", "when beep then drop at greeting", `unknown anchor "greeting"`},
+		{"malformed offset", "when beep then drop at beep+soon", `malformed offset "+soon"`},
+		{"reason on wait", `when beep then wait reason "hold on"`, "'wait' takes no reason"},
+		{"unterminated reason", `when beep then drop at beep reason "after the beep`, "malformed reason"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRules(strings.NewReader(tt.rules), "test.rules", config.DefaultConfig())
+			if err == nil {
+				t.Fatalf("expected error containing %q, got none", tt.err)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, got %q", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestParseRulesReportsLine(t *testing.T) {
+	_, err := ParseRules(strings.NewReader("# header\nwhen beep then wait\n\nwhen bogus then wait\n"), "test.rules", config.DefaultConfig())
+	if err == nil || !strings.HasPrefix(err.Error(), "test.rules:4: ") {
+		t.Fatalf("expected the error on test.rules:4, got %v", err)
+	}
+}
+
+func TestParseRulesKnownNames(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Platforms = append(cfg.Platforms, config.PlatformFingerprint{Name: "regional_carrier"})
+
+	for _, flag := range []string{"signal:dtmf", "signal:restart", "phrase:beep_cue", "phrase:leave_message", "phrase:callback",
+		"phrase:other", "platform:carrier_default", "platform:regional_carrier"} {
+		t.Run(flag, func(t *testing.T) {
+			if _, err := ParseRules(strings.NewReader("when "+flag+" then wait"), "test.rules", cfg); err != nil {
+				t.Fatalf("expected the rule to load, got %v", err)
+			}
+		})
+	}
+
+	// Platforms come from the config the rules are loaded with
+	if _, err := ParseRules(strings.NewReader("when platform:regional_carrier then wait"), "test.rules",
+		config.DefaultConfig()); err == nil {
+		t.Fatal("expected a platform missing from the config to be rejected")
+	}
+}
+
+func TestParseRulesAnchorGuards(t *testing.T) {
+	tests := []struct {
+		rule string
+		ok   bool
+	}{
+		{"when elapsed > 5s then drop at now", true},
+		{"when beepConfirmed then drop at beep", true},
+		{"when beepConfidence >= 0.6 then drop at beep", true},
+		{"when hadSpeech then drop at beep", false},
+		{"when not beep then drop at beep", false},
+		{"when silence >= 2s then drop at silence", true},
+		{"when silenceConfirmed then drop at silence", true},
+		{"when inSilence then drop at silence", false},
+		{"when not silenceConfirmed and elapsed > 1s then drop at silence", false},
+		{"when currentSilence >= 1s then drop at currentSilence", true},
+		{"when phrase:callback then drop at phrase", true},
+		{"when sincePhrase > 1s then drop at phrase", true},
+		{"when expectsBeep then drop at phrase", false},
+		{"when musicStopped then drop at musicStop", true},
+		{"when sinceMusicStop > 1s then drop at musicStop", true},
+		{"when voice then drop at musicStop", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			_, err := ParseRules(strings.NewReader(tt.rule), "test.rules", config.DefaultConfig())
+			if tt.ok && err != nil {
+				t.Fatalf("expected the rule to load, got %v", err)
+			}
+			if !tt.ok && (err == nil || !strings.Contains(err.Error(), "may not exist")) {
+				t.Fatalf("expected an anchor guard error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseRulesOffsets(t *testing.T) {
+	tests := []struct {
+		anchor string
+		name   string
+		offset time.Duration
+	}{
+		{"now", "now", 0},
+		{"beep+50ms", "beep", 50 * time.Millisecond},
+		{"beep-100ms", "beep", -100 * time.Millisecond},
+		{"currentSilence-1.5s", "currentSilence", -1500 * time.Millisecond},
+		{"silence+1m", "silence", time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.anchor, func(t *testing.T) {
+			p, err := ParseRules(strings.NewReader("when beep and silence > 1s and currentSilence > 1s then drop at "+tt.anchor), "test.rules", config.DefaultConfig())
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := p.rules[0]
+			if r.anchor != tt.name || r.offset != tt.offset {
+				t.Fatalf("expected anchor %q offset %v, got %q offset %v", tt.name, tt.offset, r.anchor, r.offset)
+			}
+		})
+	}
+
+	// The offset applies to the anchor's time when the rule fires
+	p, err := ParseRules(strings.NewReader("when beep then drop at beep-20ms"), "test.rules", config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &State{Beep: &detector.BeepEvent{EndTime: 3 * time.Second}}
+	d := p.Decide(s, 4*time.Second)
+	if d == nil || d.DropTime != 2980*time.Millisecond {
+		t.Fatalf("expected a drop at 2.98s, got %+v", d)
+	}
+}
+
+func TestParseRulesReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   string
+		reason string
+	}{
+		{"plain", `when beep then drop at beep reason "Beep - dropping"`, "Beep - dropping"},
+		{"escaped quotes", `when beep then drop at beep reason "said \"bye\", dropping"`, `said "bye", dropping`},
+		{"keywords inside", `when beep then drop at beep reason "when in doubt then drop at beep"`, "when in doubt then drop at beep"},
+		{"default", "when beep then drop at beep+50ms", "Rule: when beep then drop at beep+50ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseRules(strings.NewReader(tt.rule), "test.rules", config.DefaultConfig())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.rules[0].reason; got != tt.reason {
+				t.Fatalf("expected reason %q, got %q", tt.reason, got)
+			}
+		})
+	}
+}
+
+// recordingPolicy decides like PriorityPolicy and keeps every state it saw
+type recordingPolicy struct {
+	PriorityPolicy
+	states []State
+	times  []time.Duration
+}
+
+func (p *recordingPolicy) Decide(s *State, now time.Duration) *Decision {
+	p.states = append(p.states, *s)
+	p.times = append(p.times, now)
+	return p.PriorityPolicy.Decide(s, now)
+}
+
+// TestPriorityRulesMatchPolicy checks that rules/priority.rules decides like
+// the built-in PriorityPolicy on the states the engine recorded while
+// analyzing the sample voicemails, with and without call context hints
+func TestPriorityRulesMatchPolicy(t *testing.T) {
+	rules, err := LoadRules("../../rules/priority.rules", config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob("../../voicemails/*.wav")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no sample voicemails: %v", err)
+	}
+
+	var recorder *recordingPolicy
+	RegisterPolicy("recording", func(*config.Config) (Policy, error) {
+		recorder = &recordingPolicy{}
+		return recorder, nil
+	})
+
+	contexts := []CallContext{{}, {PriorOutcome: PriorBeep}, {PriorOutcome: PriorNoBeep}}
+	for _, file := range files {
+		for _, ctx := range contexts {
+			for _, adaptive := range []bool{false, true} {
+				cfg := config.DefaultConfig()
+				cfg.EnableSTT = false
+				cfg.AdaptiveSilence = adaptive
+				cfg.DecisionPolicy = "recording"
+
+				e := NewDecisionEngine(cfg, 8000)
+				e.SetContext(ctx)
+				if _, err := e.Process(file); err != nil {
+					t.Fatalf("%s: %v", file, err)
+				}
+				for i := range recorder.states {
+					compareDecisions(t, filepath.Base(file), &recorder.states[i], recorder.times[i], rules)
+				}
+			}
+		}
+	}
+}
+
+// TestPriorityRulesMatchPolicyOnPhrasesAndMusic covers the branches the sample
+// voicemails never reach: end phrases, music stopping and the callee's name
+func TestPriorityRulesMatchPolicyOnPhrasesAndMusic(t *testing.T) {
+	rules, err := LoadRules("../../rules/priority.rules", config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	waits := Waits{
+		BeepVerify:    WaitPlan{Wait: PostBeepVerifyDuration},
+		PhraseSilence: WaitPlan{Wait: cfg.PhraseSilenceWait},
+		Silence:       WaitPlan{Wait: cfg.BeepWaitTimeout},
+		Confirm:       WaitPlan{Wait: cfg.SilenceConfirmDur},
+		ExpectsBeep:   WaitPlan{Wait: cfg.ExpectsBeepWait},
+		MusicStop:     WaitPlan{Wait: cfg.MusicStopWait},
+	}
+	callback := &detector.PhraseEvent{Timestamp: 2 * time.Second, Category: detector.PhraseCallback}
+	beepCue := &detector.PhraseEvent{Timestamp: 2 * time.Second, Category: detector.PhraseBeepCue}
+
+	states := map[string]State{
+		"end phrase":     {PhraseFound: true, Phrase: callback, HadSpeech: true, FirstSilenceAt: 3 * time.Second},
+		"beep announced": {PhraseFound: true, ExpectsBeep: true, Phrase: beepCue, HadSpeech: true, FirstSilenceAt: 3 * time.Second},
+		"music stopped":  {HadSpeech: true, LastSpeechTime: 2 * time.Second, MusicStoppedAt: 3 * time.Second},
+		"speech after music": {HadSpeech: true, LastSpeechTime: 4 * time.Second, MusicStoppedAt: 3 * time.Second,
+			FirstSilenceAt: 4 * time.Second},
+		"pending beep": {HadSpeech: true, FirstSilenceAt: 3 * time.Second,
+			Beep: &detector.BeepEvent{EndTime: 5 * time.Second, Confidence: 0.8}},
+		"name just said": {HadSpeech: true, FirstSilenceAt: 3 * time.Second,
+			Name: &detector.PhraseEvent{Timestamp: 4 * time.Second, Category: detector.PhrasePersonal}},
+	}
+
+	for name, base := range states {
+		for _, ctx := range []CallContext{{}, {PriorOutcome: PriorBeep}, {PriorOutcome: PriorNoBeep}} {
+			s := base
+			s.Config = cfg
+			s.Waits = waits
+			s.Context = ctx
+			for now := time.Duration(0); now <= 12*time.Second; now += 20 * time.Millisecond {
+				compareDecisions(t, name, &s, now, rules)
+			}
+		}
+	}
+}
+
+func compareDecisions(t *testing.T, name string, s *State, now time.Duration, rules *RulePolicy) {
+	t.Helper()
+	want := (&PriorityPolicy{}).Decide(s, now)
+	got := rules.Decide(s, now)
+	switch {
+	case want == nil && got == nil:
+	case want == nil || got == nil:
+		t.Fatalf("%s at %v (prior %q): priority decided %v, rules decided %v", name, now, s.Context.PriorOutcome, want, got)
+	case want.DropTime != got.DropTime:
+		t.Fatalf("%s at %v (prior %q): priority drops at %v (%s), rules at %v (%s)",
+			name, now, s.Context.PriorOutcome, want.DropTime, want.Reason, got.DropTime, got.Reason)
+	}
+}